@@ -0,0 +1,386 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cgroup exports the memory, CPU and pressure stats of the cgroup of
+// a memcached process, which limits memcached independently of its own
+// memory limit.
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	subsystem = "cgroup"
+
+	// unlimited is the smallest value cgroup v1 reports for an unset limit,
+	// which is the page counter maximum rounded down to a page.
+	unlimited = 1 << 62
+)
+
+// Opts configure where the cgroup of the process is looked up.
+type Opts struct {
+	// ProcRoot is the procfs mount point, /proc by default.
+	ProcRoot string
+	// CgroupRoot is the cgroupfs mount point, /sys/fs/cgroup by default.
+	CgroupRoot string
+}
+
+// Collector exports the stats of the cgroup of the memcached process.
+type Collector struct {
+	pidFn    func() (int, error)
+	maxBytes func() float64
+	logger   *slog.Logger
+	opts     Opts
+
+	info              *prometheus.Desc
+	memoryUsage       *prometheus.Desc
+	memoryLimit       *prometheus.Desc
+	memoryHeadroom    *prometheus.Desc
+	memoryEvents      *prometheus.Desc
+	cpuPeriods        *prometheus.Desc
+	cpuThrottled      *prometheus.Desc
+	cpuThrottledTime  *prometheus.Desc
+	pressureStallTime *prometheus.Desc
+}
+
+// New returns a Collector of the cgroup of the process returned by pidFn.
+// maxBytes returns limit_maxbytes of the server, or 0 if it is unknown, in
+// which case the memory headroom is not exported.
+func New(pidFn func() (int, error), namespace string, maxBytes func() float64, logger *slog.Logger, opts Opts) *Collector {
+	if opts.ProcRoot == "" {
+		opts.ProcRoot = "/proc"
+	}
+	if opts.CgroupRoot == "" {
+		opts.CgroupRoot = "/sys/fs/cgroup"
+	}
+	return &Collector{
+		pidFn:    pidFn,
+		maxBytes: maxBytes,
+		logger:   logger,
+		opts:     opts,
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"The cgroup of the memcached process and the cgroup version.",
+			[]string{"path", "version"},
+			nil,
+		),
+		memoryUsage: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "memory_usage_bytes"),
+			"Memory used by the cgroup, including the page cache.",
+			nil,
+			nil,
+		),
+		memoryLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "memory_limit_bytes"),
+			"Memory limit of the cgroup. Not exported without a limit.",
+			nil,
+			nil,
+		),
+		memoryHeadroom: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "memory_headroom_bytes"),
+			"Memory limit of the cgroup minus the memory limit of memcached, limit_maxbytes. Memcached risks being OOM killed before it fills its cache if this is small or negative.",
+			nil,
+			nil,
+		),
+		memoryEvents: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "memory_events_total"),
+			"Memory events of the cgroup, like oom and oom_kill.",
+			[]string{"event"},
+			nil,
+		),
+		cpuPeriods: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "cpu_periods_total"),
+			"Number of enforcement periods of the CPU quota of the cgroup.",
+			nil,
+			nil,
+		),
+		cpuThrottled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "cpu_throttled_periods_total"),
+			"Number of enforcement periods in which the cgroup was throttled.",
+			nil,
+			nil,
+		),
+		cpuThrottledTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "cpu_throttled_seconds_total"),
+			"Total time the cgroup was throttled.",
+			nil,
+			nil,
+		),
+		pressureStallTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "pressure_stall_seconds_total"),
+			"Total time some or all tasks of the cgroup were stalled waiting for a resource, from the pressure stall information of cgroup v2.",
+			[]string{"resource", "kind"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.memoryUsage
+	ch <- c.memoryLimit
+	ch <- c.memoryHeadroom
+	ch <- c.memoryEvents
+	ch <- c.cpuPeriods
+	ch <- c.cpuThrottled
+	ch <- c.cpuThrottledTime
+	ch <- c.pressureStallTime
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	pid, err := c.pidFn()
+	if err != nil {
+		c.logger.Error("Failed to get the memcached PID", "err", err)
+		return
+	}
+	cg, err := c.find(pid)
+	if err != nil {
+		c.logger.Error("Failed to find the cgroup of memcached", "pid", pid, "err", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, cg.path, strconv.Itoa(cg.version))
+
+	var limit float64
+	if cg.version == 2 {
+		limit, err = c.collectV2(ch, cg)
+	} else {
+		limit, err = c.collectV1(ch, cg)
+	}
+	if err != nil {
+		c.logger.Error("Failed to read cgroup stats", "path", cg.path, "err", err)
+		return
+	}
+
+	if limit > 0 {
+		ch <- prometheus.MustNewConstMetric(c.memoryLimit, prometheus.GaugeValue, limit)
+		if maxBytes := c.maxBytes(); maxBytes > 0 {
+			ch <- prometheus.MustNewConstMetric(c.memoryHeadroom, prometheus.GaugeValue, limit-maxBytes)
+		}
+	}
+}
+
+// cgroup is the cgroup of a process.
+type cgroup struct {
+	path    string
+	version int
+	// memory and cpu are the directories of the controllers.
+	memory string
+	cpu    string
+}
+
+// find returns the cgroup of pid from /proc/<pid>/cgroup. The unified
+// hierarchy is used if it is mounted at the cgroup root, otherwise the memory
+// and cpu controllers of cgroup v1.
+func (c *Collector) find(pid int) (cgroup, error) {
+	data, err := os.ReadFile(filepath.Join(c.opts.ProcRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return cgroup{}, err
+	}
+	_, err = os.Stat(filepath.Join(c.opts.CgroupRoot, "cgroup.controllers"))
+	unified := err == nil
+
+	cg := cgroup{version: 1}
+	for line := range strings.SplitSeq(strings.TrimSpace(string(data)), "\n") {
+		f := strings.SplitN(line, ":", 3)
+		if len(f) != 3 {
+			return cgroup{}, fmt.Errorf("invalid cgroup line %q", line)
+		}
+		if unified {
+			if f[0] == "0" && f[1] == "" {
+				dir := filepath.Join(c.opts.CgroupRoot, f[2])
+				return cgroup{path: f[2], version: 2, memory: dir, cpu: dir}, nil
+			}
+			continue
+		}
+		for controller := range strings.SplitSeq(f[1], ",") {
+			// The mount point of co-mounted controllers is named after all of
+			// them, like cpu,cpuacct.
+			dir := filepath.Join(c.opts.CgroupRoot, f[1], f[2])
+			switch controller {
+			case "memory":
+				cg.path, cg.memory = f[2], dir
+			case "cpu":
+				cg.cpu = dir
+			}
+		}
+	}
+	if cg.memory == "" {
+		return cgroup{}, errors.New("no memory cgroup found")
+	}
+	return cg, nil
+}
+
+// collectV2 sends the stats of a cgroup v2 and returns its memory limit.
+func (c *Collector) collectV2(ch chan<- prometheus.Metric, cg cgroup) (float64, error) {
+	usage, err := readValue(filepath.Join(cg.memory, "memory.current"))
+	if err != nil {
+		return 0, err
+	}
+	ch <- prometheus.MustNewConstMetric(c.memoryUsage, prometheus.GaugeValue, usage)
+
+	var limit float64
+	if v, err := readString(filepath.Join(cg.memory, "memory.max")); err != nil {
+		return 0, err
+	} else if v != "max" {
+		if limit, err = strconv.ParseFloat(v, 64); err != nil {
+			return 0, err
+		}
+	}
+
+	events, err := readKeyValues(filepath.Join(cg.memory, "memory.events"))
+	if err != nil {
+		return 0, err
+	}
+	for event, v := range events {
+		ch <- prometheus.MustNewConstMetric(c.memoryEvents, prometheus.CounterValue, v, event)
+	}
+
+	// cpu.stat only has throttling stats if the cpu controller is enabled.
+	if stat, err := readKeyValues(filepath.Join(cg.cpu, "cpu.stat")); err == nil {
+		c.collectThrottling(ch, stat, "throttled_usec", 1e-6)
+	}
+
+	// Pressure stall information might be disabled in the kernel.
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		pressure, err := readPressure(filepath.Join(cg.memory, resource+".pressure"))
+		if err != nil {
+			continue
+		}
+		for kind, total := range pressure {
+			ch <- prometheus.MustNewConstMetric(c.pressureStallTime, prometheus.CounterValue, total, resource, kind)
+		}
+	}
+	return limit, nil
+}
+
+// collectV1 sends the stats of the cgroup v1 controllers and returns the
+// memory limit.
+func (c *Collector) collectV1(ch chan<- prometheus.Metric, cg cgroup) (float64, error) {
+	usage, err := readValue(filepath.Join(cg.memory, "memory.usage_in_bytes"))
+	if err != nil {
+		return 0, err
+	}
+	ch <- prometheus.MustNewConstMetric(c.memoryUsage, prometheus.GaugeValue, usage)
+
+	limit, err := readValue(filepath.Join(cg.memory, "memory.limit_in_bytes"))
+	if err != nil {
+		return 0, err
+	}
+	if limit >= unlimited {
+		limit = 0
+	}
+
+	// oom_kill was added to memory.oom_control in Linux 4.13.
+	if control, err := readKeyValues(filepath.Join(cg.memory, "memory.oom_control")); err == nil {
+		if v, ok := control["oom_kill"]; ok {
+			ch <- prometheus.MustNewConstMetric(c.memoryEvents, prometheus.CounterValue, v, "oom_kill")
+		}
+	}
+
+	if cg.cpu != "" {
+		if stat, err := readKeyValues(filepath.Join(cg.cpu, "cpu.stat")); err == nil {
+			c.collectThrottling(ch, stat, "throttled_time", 1e-9)
+		}
+	}
+	return limit, nil
+}
+
+func (c *Collector) collectThrottling(ch chan<- prometheus.Metric, stat map[string]float64, throttledTime string, unit float64) {
+	periods, ok := stat["nr_periods"]
+	if !ok {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.cpuPeriods, prometheus.CounterValue, periods)
+	ch <- prometheus.MustNewConstMetric(c.cpuThrottled, prometheus.CounterValue, stat["nr_throttled"])
+	ch <- prometheus.MustNewConstMetric(c.cpuThrottledTime, prometheus.CounterValue, stat[throttledTime]*unit)
+}
+
+func readString(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readValue(filename string) (float64, error) {
+	v, err := readString(filename)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// readKeyValues reads a flat keyed file like memory.events.
+func readKeyValues(filename string) (map[string]float64, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]float64{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", filename, err)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// readPressure returns the total stall time in seconds by kind, some or full,
+// of a pressure file like "some avg10=0.00 avg60=0.00 avg300=0.00 total=0".
+func readPressure(filename string) (map[string]float64, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	totals := map[string]float64{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			total, ok := strings.CutPrefix(field, "total=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(total, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", filename, err)
+			}
+			totals[fields[0]] = v / 1e6
+		}
+	}
+	return totals, nil
+}
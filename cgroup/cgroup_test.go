@@ -0,0 +1,168 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	pidFn := func() (int, error) { return 100, nil }
+	maxBytes := func() float64 { return 805306368 }
+
+	t.Run("v2", func(t *testing.T) {
+		proc, cgroupfs := t.TempDir(), t.TempDir()
+		writeFiles(t, proc, map[string]string{"100/cgroup": "0::/kubepods/pod1/memcached\n"})
+		writeFiles(t, cgroupfs, map[string]string{
+			"cgroup.controllers":                             "cpuset cpu io memory pids\n",
+			"kubepods/pod1/memcached/memory.current":         "536870912\n",
+			"kubepods/pod1/memcached/memory.max":             "1073741824\n",
+			"kubepods/pod1/memcached/memory.events":          "low 0\nhigh 0\nmax 12\noom 2\noom_kill 1\noom_group_kill 0\n",
+			"kubepods/pod1/memcached/cpu.stat":               "usage_usec 1000\nuser_usec 800\nsystem_usec 200\nnr_periods 100\nnr_throttled 10\nthrottled_usec 2500000\n",
+			"kubepods/pod1/memcached/cpu.pressure":           "some avg10=0.00 avg60=0.00 avg300=0.00 total=1500000\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=500000\n",
+			"kubepods/pod1/memcached/memory.pressure":        "some avg10=1.00 avg60=0.50 avg300=0.10 total=3000000\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=1000000\n",
+			"kubepods/pod1/memcached/cgroup.subtree_control": "",
+		})
+		c := New(pidFn, "memcached", maxBytes, promslog.NewNopLogger(), Opts{ProcRoot: proc, CgroupRoot: cgroupfs})
+
+		want := `
+# HELP memcached_cgroup_cpu_periods_total Number of enforcement periods of the CPU quota of the cgroup.
+# TYPE memcached_cgroup_cpu_periods_total counter
+memcached_cgroup_cpu_periods_total 100
+# HELP memcached_cgroup_cpu_throttled_periods_total Number of enforcement periods in which the cgroup was throttled.
+# TYPE memcached_cgroup_cpu_throttled_periods_total counter
+memcached_cgroup_cpu_throttled_periods_total 10
+# HELP memcached_cgroup_cpu_throttled_seconds_total Total time the cgroup was throttled.
+# TYPE memcached_cgroup_cpu_throttled_seconds_total counter
+memcached_cgroup_cpu_throttled_seconds_total 2.5
+# HELP memcached_cgroup_info The cgroup of the memcached process and the cgroup version.
+# TYPE memcached_cgroup_info gauge
+memcached_cgroup_info{path="/kubepods/pod1/memcached",version="2"} 1
+# HELP memcached_cgroup_memory_events_total Memory events of the cgroup, like oom and oom_kill.
+# TYPE memcached_cgroup_memory_events_total counter
+memcached_cgroup_memory_events_total{event="high"} 0
+memcached_cgroup_memory_events_total{event="low"} 0
+memcached_cgroup_memory_events_total{event="max"} 12
+memcached_cgroup_memory_events_total{event="oom"} 2
+memcached_cgroup_memory_events_total{event="oom_group_kill"} 0
+memcached_cgroup_memory_events_total{event="oom_kill"} 1
+# HELP memcached_cgroup_memory_headroom_bytes Memory limit of the cgroup minus the memory limit of memcached, limit_maxbytes. Memcached risks being OOM killed before it fills its cache if this is small or negative.
+# TYPE memcached_cgroup_memory_headroom_bytes gauge
+memcached_cgroup_memory_headroom_bytes 2.68435456e+08
+# HELP memcached_cgroup_memory_limit_bytes Memory limit of the cgroup. Not exported without a limit.
+# TYPE memcached_cgroup_memory_limit_bytes gauge
+memcached_cgroup_memory_limit_bytes 1.073741824e+09
+# HELP memcached_cgroup_memory_usage_bytes Memory used by the cgroup, including the page cache.
+# TYPE memcached_cgroup_memory_usage_bytes gauge
+memcached_cgroup_memory_usage_bytes 5.36870912e+08
+# HELP memcached_cgroup_pressure_stall_seconds_total Total time some or all tasks of the cgroup were stalled waiting for a resource, from the pressure stall information of cgroup v2.
+# TYPE memcached_cgroup_pressure_stall_seconds_total counter
+memcached_cgroup_pressure_stall_seconds_total{kind="full",resource="cpu"} 0.5
+memcached_cgroup_pressure_stall_seconds_total{kind="full",resource="memory"} 1
+memcached_cgroup_pressure_stall_seconds_total{kind="some",resource="cpu"} 1.5
+memcached_cgroup_pressure_stall_seconds_total{kind="some",resource="memory"} 3
+`
+		if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+			t.Error(err)
+		}
+
+		// Without a limit there is no headroom.
+		writeFiles(t, cgroupfs, map[string]string{"kubepods/pod1/memcached/memory.max": "max\n"})
+		if n := testutil.CollectAndCount(c, "memcached_cgroup_memory_limit_bytes", "memcached_cgroup_memory_headroom_bytes"); n != 0 {
+			t.Errorf("want no limit metrics, got %d", n)
+		}
+	})
+
+	t.Run("v1", func(t *testing.T) {
+		proc, cgroupfs := t.TempDir(), t.TempDir()
+		writeFiles(t, proc, map[string]string{"100/cgroup": "12:pids:/docker/abc\n" +
+			"5:cpu,cpuacct:/docker/abc\n" +
+			"4:memory:/docker/abc\n" +
+			"1:name=systemd:/docker/abc\n" +
+			"0::/system.slice/containerd.service\n"})
+		writeFiles(t, cgroupfs, map[string]string{
+			"memory/docker/abc/memory.usage_in_bytes": "536870912\n",
+			"memory/docker/abc/memory.limit_in_bytes": "9223372036854771712\n",
+			"memory/docker/abc/memory.oom_control":    "oom_kill_disable 0\nunder_oom 0\noom_kill 3\n",
+			"cpu,cpuacct/docker/abc/cpu.stat":         "nr_periods 50\nnr_throttled 5\nthrottled_time 1500000000\n",
+		})
+		c := New(pidFn, "memcached", maxBytes, promslog.NewNopLogger(), Opts{ProcRoot: proc, CgroupRoot: cgroupfs})
+
+		want := `
+# HELP memcached_cgroup_cpu_periods_total Number of enforcement periods of the CPU quota of the cgroup.
+# TYPE memcached_cgroup_cpu_periods_total counter
+memcached_cgroup_cpu_periods_total 50
+# HELP memcached_cgroup_cpu_throttled_periods_total Number of enforcement periods in which the cgroup was throttled.
+# TYPE memcached_cgroup_cpu_throttled_periods_total counter
+memcached_cgroup_cpu_throttled_periods_total 5
+# HELP memcached_cgroup_cpu_throttled_seconds_total Total time the cgroup was throttled.
+# TYPE memcached_cgroup_cpu_throttled_seconds_total counter
+memcached_cgroup_cpu_throttled_seconds_total 1.5
+# HELP memcached_cgroup_info The cgroup of the memcached process and the cgroup version.
+# TYPE memcached_cgroup_info gauge
+memcached_cgroup_info{path="/docker/abc",version="1"} 1
+# HELP memcached_cgroup_memory_events_total Memory events of the cgroup, like oom and oom_kill.
+# TYPE memcached_cgroup_memory_events_total counter
+memcached_cgroup_memory_events_total{event="oom_kill"} 3
+# HELP memcached_cgroup_memory_usage_bytes Memory used by the cgroup, including the page cache.
+# TYPE memcached_cgroup_memory_usage_bytes gauge
+memcached_cgroup_memory_usage_bytes 5.36870912e+08
+`
+		if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+			t.Error(err)
+		}
+
+		writeFiles(t, cgroupfs, map[string]string{"memory/docker/abc/memory.limit_in_bytes": "268435456\n"})
+		want = `
+# HELP memcached_cgroup_memory_headroom_bytes Memory limit of the cgroup minus the memory limit of memcached, limit_maxbytes. Memcached risks being OOM killed before it fills its cache if this is small or negative.
+# TYPE memcached_cgroup_memory_headroom_bytes gauge
+memcached_cgroup_memory_headroom_bytes -5.36870912e+08
+`
+		if err := testutil.CollectAndCompare(c, strings.NewReader(want), "memcached_cgroup_memory_headroom_bytes"); err != nil {
+			t.Error(err)
+		}
+
+		// Without a scrape of memcached the headroom is unknown.
+		c = New(pidFn, "memcached", func() float64 { return 0 }, promslog.NewNopLogger(), Opts{ProcRoot: proc, CgroupRoot: cgroupfs})
+		if n := testutil.CollectAndCount(c, "memcached_cgroup_memory_headroom_bytes"); n != 0 {
+			t.Errorf("want no headroom, got %d", n)
+		}
+	})
+
+	t.Run("No process", func(t *testing.T) {
+		c := New(pidFn, "memcached", maxBytes, promslog.NewNopLogger(), Opts{ProcRoot: t.TempDir(), CgroupRoot: t.TempDir()})
+		if n := testutil.CollectAndCount(c); n != 0 {
+			t.Errorf("want no metrics, got %d", n)
+		}
+	})
+}
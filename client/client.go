@@ -0,0 +1,219 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client implements a minimal memcached connection speaking the raw
+// text and meta protocols, for the commands the gomemcache client does not
+// support.
+package client
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrServer is returned when the server answers with ERROR,
+	// CLIENT_ERROR or SERVER_ERROR.
+	ErrServer = errors.New("memcached returned an error")
+
+	resultEnd = "END"
+)
+
+// Conn is a connection to a single memcached server. It is not safe for
+// concurrent use.
+type Conn struct {
+	nc      net.Conn
+	rw      *bufio.ReadWriter
+	timeout time.Duration
+	timings Timings
+}
+
+// Timings are the durations of establishing a connection.
+type Timings struct {
+	Dial time.Duration
+	// TLSHandshake is zero for connections without TLS.
+	TLSHandshake time.Duration
+}
+
+// Network returns the network of a memcached address, which is either
+// host:port or the path of a unix socket.
+func Network(address string) string {
+	if strings.Contains(address, "/") {
+		return "unix"
+	}
+	return "tcp"
+}
+
+// Dial connects to the memcached server at address. The timeout applies to
+// the connection attempt and to every subsequent command.
+func Dial(address string, timeout time.Duration, tlsConfig *tls.Config) (*Conn, error) {
+	var timings Timings
+	start := time.Now()
+	d := net.Dialer{Timeout: timeout}
+	nc, err := d.Dial(Network(address), address)
+	if err != nil {
+		return nil, err
+	}
+	timings.Dial = time.Since(start)
+
+	if tlsConfig != nil {
+		start = time.Now()
+		tc := tls.Client(nc, serverName(tlsConfig, address))
+		if err := tc.SetDeadline(time.Now().Add(timeout)); err != nil {
+			nc.Close()
+			return nil, err
+		}
+		if err := tc.Handshake(); err != nil {
+			nc.Close()
+			return nil, err
+		}
+		timings.TLSHandshake = time.Since(start)
+		nc = tc
+	}
+	return &Conn{
+		nc:      nc,
+		rw:      bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc)),
+		timeout: timeout,
+		timings: timings,
+	}, nil
+}
+
+// serverName defaults the server name of tlsConfig to the host of address,
+// like tls.Dial. Unix sockets have no host to verify, so the server name is
+// left to the configuration.
+func serverName(tlsConfig *tls.Config, address string) *tls.Config {
+	if tlsConfig.ServerName != "" || Network(address) == "unix" {
+		return tlsConfig
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	c := tlsConfig.Clone()
+	c.ServerName = host
+	return c
+}
+
+// Timings returns how long establishing the connection took.
+func (c *Conn) Timings() Timings {
+	return c.timings
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.nc.Close()
+}
+
+// SetDeadline overrides the deadline of the connection, for example for
+// long-running streams. A zero value disables the deadline.
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.nc.SetDeadline(t)
+}
+
+// Send writes a command line and the optional data block following it. The
+// deadline of the connection is extended by the timeout.
+func (c *Conn) Send(line string, data []byte) error {
+	if err := c.nc.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return err
+	}
+	if _, err := c.rw.WriteString(line + "\r\n"); err != nil {
+		return err
+	}
+	if data != nil {
+		if _, err := c.rw.Write(data); err != nil {
+			return err
+		}
+		if _, err := c.rw.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return c.rw.Flush()
+}
+
+// ReadLine reads a single response line without its line ending. Error
+// responses are returned as ErrServer.
+func (c *Conn) ReadLine() (string, error) {
+	line, err := c.rw.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "ERROR" || strings.HasPrefix(line, "CLIENT_ERROR") || strings.HasPrefix(line, "SERVER_ERROR") {
+		return line, fmt.Errorf("%w: %s", ErrServer, line)
+	}
+	return line, nil
+}
+
+// ReadData reads a data block of n bytes followed by a line ending.
+func (c *Conn) ReadData(n int) ([]byte, error) {
+	b := make([]byte, n+2)
+	if _, err := io.ReadFull(c.rw, b); err != nil {
+		return nil, err
+	}
+	if string(b[n:]) != "\r\n" {
+		return nil, errors.New("corrupt data block")
+	}
+	return b[:n], nil
+}
+
+// Command sends a command line and returns the first response line.
+func (c *Conn) Command(line string) (string, error) {
+	if err := c.Send(line, nil); err != nil {
+		return "", err
+	}
+	return c.ReadLine()
+}
+
+// Stats runs "stats" with the given arguments and returns the values of the
+// STAT lines.
+func (c *Conn) Stats(args ...string) (map[string]string, error) {
+	cmd := strings.Join(append([]string{"stats"}, args...), " ")
+	if err := c.Send(cmd, nil); err != nil {
+		return nil, err
+	}
+
+	stats := map[string]string{}
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == resultEnd {
+			return stats, nil
+		}
+		f := strings.SplitN(line, " ", 3)
+		if len(f) != 3 || f[0] != "STAT" {
+			return nil, fmt.Errorf("unexpected stats line %q", line)
+		}
+		stats[f[1]] = f[2]
+	}
+}
+
+// Version returns the version string reported by the server.
+func (c *Conn) Version() (string, error) {
+	line, err := c.Command("version")
+	if err != nil {
+		return "", err
+	}
+	v, ok := strings.CutPrefix(line, "VERSION ")
+	if !ok {
+		return "", fmt.Errorf("unexpected version response %q", line)
+	}
+	return v, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestConn(t *testing.T) {
+	srv := memcachedtest.NewServer(t, func(w *bufio.Writer, _ *bufio.Reader, line string) bool {
+		switch line {
+		case "version":
+			w.WriteString("VERSION 1.6.21\r\n")
+		case "stats settings":
+			memcachedtest.WriteStats(w, map[string]string{"maxconns": "1024", "evictions": "on"})
+		case "mg foo v":
+			w.WriteString("VA 3\r\nbar\r\n")
+		case "stats broken":
+			w.WriteString("garbage\r\n")
+		default:
+			w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		}
+		return true
+	})
+
+	c, err := Dial(srv.Addr, time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	v, err := c.Version()
+	if err != nil || v != "1.6.21" {
+		t.Errorf("want version 1.6.21, got %q (%v)", v, err)
+	}
+
+	s, err := c.Stats("settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s["maxconns"] != "1024" || s["evictions"] != "on" {
+		t.Errorf("unexpected settings %v", s)
+	}
+
+	line, err := c.Command("mg foo v")
+	if err != nil || line != "VA 3" {
+		t.Fatalf("unexpected response %q (%v)", line, err)
+	}
+	data, err := c.ReadData(3)
+	if err != nil || string(data) != "bar" {
+		t.Errorf("want data bar, got %q (%v)", data, err)
+	}
+
+	if _, err := c.Stats("broken"); err == nil {
+		t.Error("expect return error but not")
+	}
+
+	if _, err := c.Command("unknown"); !errors.Is(err, ErrServer) {
+		t.Errorf("want server error, got %v", err)
+	}
+}
+
+func TestTimings(t *testing.T) {
+	t.Run("Plain", func(t *testing.T) {
+		srv := memcachedtest.NewServer(t, func(*bufio.Writer, *bufio.Reader, string) bool { return true })
+		c, err := Dial(srv.Addr, time.Second, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		if timings := c.Timings(); timings.Dial <= 0 || timings.TLSHandshake != 0 {
+			t.Errorf("unexpected timings %+v", timings)
+		}
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		srv := httptest.NewTLSServer(http.NotFoundHandler())
+		defer srv.Close()
+		tlsConfig := srv.Client().Transport.(*http.Transport).TLSClientConfig
+
+		c, err := Dial(strings.TrimPrefix(srv.URL, "https://"), time.Second, tlsConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		if timings := c.Timings(); timings.Dial <= 0 || timings.TLSHandshake <= 0 {
+			t.Errorf("unexpected timings %+v", timings)
+		}
+	})
+}
+
+func TestNetwork(t *testing.T) {
+	for address, want := range map[string]string{
+		"localhost:11211":             "tcp",
+		"[::1]:11211":                 "tcp",
+		"/var/run/memcached.sock":     "unix",
+		"./relative/memcached.socket": "unix",
+	} {
+		if got := Network(address); got != want {
+			t.Errorf("Network(%q) = %q, want %q", address, got, want)
+		}
+	}
+}
+
+func TestServerName(t *testing.T) {
+	for address, want := range map[string]string{
+		"localhost:11211":         "localhost",
+		"10.0.0.1:11211":          "10.0.0.1",
+		"[::1]:11211":             "::1",
+		"[fe80::1%eth0]:11211":    "fe80::1%eth0",
+		"/var/run/memcached.sock": "",
+	} {
+		if got := serverName(&tls.Config{}, address).ServerName; got != want {
+			t.Errorf("serverName(%q) = %q, want %q", address, got, want)
+		}
+	}
+
+	if got := serverName(&tls.Config{ServerName: "memcached"}, "[::1]:11211").ServerName; got != "memcached" {
+		t.Errorf("configured server name overridden with %q", got)
+	}
+}
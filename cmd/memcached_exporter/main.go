@@ -14,15 +14,16 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/collectors"
 	versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	promconfig "github.com/prometheus/common/config"
@@ -32,41 +33,135 @@ import (
 	"github.com/prometheus/exporter-toolkit/web"
 	webflag "github.com/prometheus/exporter-toolkit/web/kingpinflag"
 
+	"github.com/prometheus/memcached_exporter/config"
+	"github.com/prometheus/memcached_exporter/health"
+	"github.com/prometheus/memcached_exporter/keyspace"
+	"github.com/prometheus/memcached_exporter/otlp"
 	"github.com/prometheus/memcached_exporter/pkg/exporter"
+	"github.com/prometheus/memcached_exporter/probe"
+	"github.com/prometheus/memcached_exporter/process"
+	"github.com/prometheus/memcached_exporter/remotewrite"
 	"github.com/prometheus/memcached_exporter/scraper"
+	"github.com/prometheus/memcached_exporter/watch"
 )
 
 func main() {
 	var (
+		serveCmd           = kingpin.Command("serve", "Run the exporter.").Default()
+		probeCmd           = kingpin.Command("probe", "Collect the metrics of a target once, print them and exit non-zero if it is down or a threshold is breached.")
+		probeTarget        = probeCmd.Flag("target", "Memcached server to probe.").Required().String()
+		probeFormat        = probeCmd.Flag("format", "Output format.").Default(formatProm).Enum(formatProm, formatJSON, formatTable)
+		probeThresholds    = probeCmd.Flag("threshold", "Fail if any sample of a metric compares true, e.g. 'memcached_current_connections>1000', may be repeated.").Strings()
+		topCmd             = kingpin.Command("top", "Show live per-second rates of a target in the terminal.")
+		topTarget          = topCmd.Flag("target", "Memcached server to watch.").Required().String()
+		topInterval        = topCmd.Flag("interval", "Refresh interval.").Default("1s").Duration()
 		address            = kingpin.Flag("memcached.address", "Memcached server address.").Default("localhost:11211").String()
 		timeout            = kingpin.Flag("memcached.timeout", "memcached connect timeout.").Default("1s").Duration()
+		constLabels        = kingpin.Flag("memcached.const-label", "Label added to every memcached metric as name=value, may be repeated. Overridden by the labels of targets in --config.file.").StringMap()
 		pidFile            = kingpin.Flag("memcached.pid-file", "Optional path to a file containing the memcached PID for additional metrics.").Default("").String()
+		processListen      = kingpin.Flag("memcached.process.listen", "Find the memcached process listening on this port, host:port or unix socket in /proc instead of using a pid file, may be repeated.").Strings()
+		processName        = kingpin.Flag("memcached.process.name", "Find memcached processes by this process name in /proc instead of using a pid file.").Default("").String()
+		enableOSMetrics    = kingpin.Flag("memcached.process.os-metrics", "Export per-thread CPU time and context switches, memory, huge page and socket buffer metrics of the process in --memcached.pid-file or found with --memcached.process.*.").Bool()
+		enableCgroup       = kingpin.Flag("memcached.cgroup.enable", "Export memory, OOM, CPU throttling and pressure metrics of the cgroup of the process in --memcached.pid-file or found with --memcached.process.*.").Bool()
+		cgroupRoot         = kingpin.Flag("memcached.cgroup.root", "Mount point of the cgroup filesystem.").Default("/sys/fs/cgroup").String()
 		enableTLS          = kingpin.Flag("memcached.tls.enable", "Enable TLS connections to memcached").Bool()
 		certFile           = kingpin.Flag("memcached.tls.cert-file", "Client certificate file.").Default("").String()
 		keyFile            = kingpin.Flag("memcached.tls.key-file", "Client private key file.").Default("").String()
 		caFile             = kingpin.Flag("memcached.tls.ca-file", "Client root CA file.").Default("").String()
 		insecureSkipVerify = kingpin.Flag("memcached.tls.insecure-skip-verify", "Skip server certificate verification").Bool()
 		serverName         = kingpin.Flag("memcached.tls.server-name", "Memcached TLS certificate servername").Default("").String()
+		pollInterval       = kingpin.Flag("memcached.poll.interval", "Poll memcached in the background at this interval and serve the last successful snapshot. 0 disables polling.").Default("0s").Duration()
+		pollJitter         = kingpin.Flag("memcached.poll.jitter", "Maximum random delay added to every poll interval.").Default("0s").Duration()
+		pollMaxAge         = kingpin.Flag("memcached.poll.max-age", "Drop the cached snapshot once it is older than this. 0 defaults to five poll intervals.").Default("0s").Duration()
+		enableDerived      = kingpin.Flag("memcached.derived-metrics", "Export hit ratios and memory, connection and slab utilisation computed from the stats.").Bool()
+		enableLatency      = kingpin.Flag("memcached.latency-metrics", "Export native histograms of the duration of connecting to memcached and of the requests to it on every scrape, with exemplars.").Bool()
+		baselineFile       = kingpin.Flag("memcached.drift.baseline-file", "Path to a YAML file with the expected settings and stats per pool of targets.").Default("").String()
+		enableCanary       = kingpin.Flag("memcached.canary.enable", "Probe memcached with set, get, CAS and delete operations on every scrape, and enable the canary module on the scrape path.").Bool()
+		enableMeta         = kingpin.Flag("memcached.meta.enable", "Exercise the meta protocol commands ms, mg, md and mn on every scrape, and enable the meta module on the scrape path.").Bool()
+		canaryKeyPrefix    = kingpin.Flag("memcached.canary.key-prefix", "Prefix of the keys written by the canary and meta protocol probes.").Default(probe.DefaultCanaryKeyPrefix).String()
+		enableHotKeys      = kingpin.Flag("memcached.hot-keys.enable", "Follow the memcached fetchers log stream to detect hot keys.").Bool()
+		hotKeysTopK        = kingpin.Flag("memcached.hot-keys.top-k", "Number of hottest keys to export.").Default("10").Int()
+		hotKeysWindow      = kingpin.Flag("memcached.hot-keys.window", "Sliding window over which key accesses are counted.").Default("1m").Duration()
+		hotKeysSampleRate  = kingpin.Flag("memcached.hot-keys.sample-rate", "Fraction of log lines to count, between 0 and 1.").Default("1").Float64()
+		hotKeysMutations   = kingpin.Flag("memcached.hot-keys.mutations", "Also count stores from the mutations log stream.").Bool()
+		hotKeysDelimiter   = kingpin.Flag("memcached.hot-keys.prefix-delimiter", "Aggregate keys by prefix, split on this delimiter. Empty counts full keys.").Default("").String()
+		hotKeysDepth       = kingpin.Flag("memcached.hot-keys.prefix-depth", "Number of delimited key segments making up a prefix.").Default("1").Int()
+		hotKeysMaxLength   = kingpin.Flag("memcached.hot-keys.max-key-length", "Truncate exported keys to this many characters. 0 disables truncation.").Default("128").Int()
+		hotKeysHash        = kingpin.Flag("memcached.hot-keys.hash-keys", "Export a hash of each key instead of the key itself.").Bool()
+		enableEvictions    = kingpin.Flag("memcached.evictions.enable", "Follow the memcached evictions log stream to analyse evicted items.").Bool()
+		evictionsDeletions = kingpin.Flag("memcached.evictions.deletions", "Also analyse deleted items from the deletions log stream.").Bool()
+		evictionsDelimiter = kingpin.Flag("memcached.evictions.prefix-delimiter", "Count evicted items by key prefix, split on this delimiter. Empty counts full keys.").Default(":").String()
+		evictionsDepth     = kingpin.Flag("memcached.evictions.prefix-depth", "Number of delimited key segments making up a prefix.").Default("1").Int()
+		evictionsPrefixes  = kingpin.Flag("memcached.evictions.max-prefixes", "Maximum number of distinct key prefixes to export. 0 disables the limit.").Default("100").Int()
+		evictionsMaxLength = kingpin.Flag("memcached.evictions.max-key-length", "Truncate exported key prefixes to this many characters. 0 disables truncation.").Default("128").Int()
+		evictionsHash      = kingpin.Flag("memcached.evictions.hash-keys", "Export a hash of each key prefix instead of the prefix itself.").Bool()
+		enableKeyspace     = kingpin.Flag("memcached.keyspace.enable", "Periodically dump item metadata with the LRU crawler to describe the keyspace.").Bool()
+		keyspaceInterval   = kingpin.Flag("memcached.keyspace.interval", "Interval between keyspace dumps.").Default("10m").Duration()
+		keyspaceSlabs      = kingpin.Flag("memcached.keyspace.slab", "Slab class to dump, may be repeated. Dumps all classes if unset.").Ints()
+		keyspaceMaxItems   = kingpin.Flag("memcached.keyspace.max-items", "Stop a keyspace dump after this many items. 0 disables the limit.").Default("1000000").Int()
+		keyspaceMaxTime    = kingpin.Flag("memcached.keyspace.max-duration", "Stop a keyspace dump after this long.").Default("1m").Duration()
+		keyspaceRate       = kingpin.Flag("memcached.keyspace.items-per-second", "Maximum rate items are read at during a keyspace dump. 0 disables the limit.").Default("50000").Float64()
+		keyspaceCommand    = kingpin.Flag("memcached.keyspace.command", "LRU crawler command used to dump the keyspace. mgdump fetches the metadata of sampled keys with one mg request each, without bumping them in the LRU.").Default(keyspace.CommandMetadump).Enum(keyspace.CommandMetadump, keyspace.CommandMgdump)
+		keyspaceSampleRate = kingpin.Flag("memcached.keyspace.sample-rate", "Fraction of keys to account, between 0 and 1. Totals are extrapolated.").Default("1").Float64()
+		keyspaceRules      = kingpin.Flag("memcached.keyspace.prefix-rule", "Regular expression mapping keys to a prefix, may be repeated. Named capture groups become labels.").Strings()
+		keyspaceDelimiter  = kingpin.Flag("memcached.keyspace.prefix-delimiter", "Account items by key prefix, split on this delimiter. Empty accounts full keys.").Default(":").String()
+		keyspaceDepth      = kingpin.Flag("memcached.keyspace.prefix-depth", "Number of delimited key segments making up a prefix.").Default("1").Int()
+		keyspacePrefixes   = kingpin.Flag("memcached.keyspace.max-prefixes", "Maximum number of distinct key prefixes to export. 0 disables the limit.").Default("100").Int()
+		otlpEndpoint       = kingpin.Flag("otlp.endpoint", "Push the metrics of the memcached server of the metrics path to this OTLP collector URL, e.g. http://localhost:4317. Empty disables pushing.").Default("").String()
+		otlpProtocol       = kingpin.Flag("otlp.protocol", "Protocol used to push OTLP metrics.").Default(otlp.ProtocolGRPC).Enum(otlp.ProtocolGRPC, otlp.ProtocolHTTP)
+		otlpInterval       = kingpin.Flag("otlp.interval", "Interval between two OTLP pushes.").Default("30s").Duration()
+		otlpHeaders        = kingpin.Flag("otlp.header", "Header sent with every OTLP push as name=value, may be repeated.").StringMap()
+		otlpServiceName    = kingpin.Flag("otlp.service-name", "service.name resource attribute of pushed metrics.").Default("memcached").String()
+		rwURL              = kingpin.Flag("remote-write.url", "Send the metrics to this Prometheus remote write URL. Empty disables remote write.").Default("").String()
+		rwInterval         = kingpin.Flag("remote-write.interval", "Interval between two remote writes.").Default("30s").Duration()
+		rwTimeout          = kingpin.Flag("remote-write.timeout", "Timeout of a remote write request.").Default("10s").Duration()
+		rwLabels           = kingpin.Flag("remote-write.label", "Label added to every series as name=value, may be repeated. Defaults to job=memcached and instance set to --memcached.address.").StringMap()
+		rwQueueCapacity    = kingpin.Flag("remote-write.queue-capacity", "Number of requests queued while the remote write endpoint is unavailable.").Default("100").Int()
+		rwMaxRetries       = kingpin.Flag("remote-write.max-retries", "Retries of a failing remote write request before it is dropped.").Default("10").Int()
+		rwMinBackoff       = kingpin.Flag("remote-write.min-backoff", "Initial delay between remote write retries.").Default("30ms").Duration()
+		rwMaxBackoff       = kingpin.Flag("remote-write.max-backoff", "Maximum delay between remote write retries.").Default("5s").Duration()
+		rwUsername         = kingpin.Flag("remote-write.basic-auth.username", "Basic auth username for remote write.").Default("").String()
+		rwPasswordFile     = kingpin.Flag("remote-write.basic-auth.password-file", "File containing the basic auth password for remote write.").Default("").String()
+		rwBearerTokenFile  = kingpin.Flag("remote-write.bearer-token-file", "File containing the bearer token for remote write.").Default("").String()
+		configFile         = kingpin.Flag("config.file", "Path to a YAML file with the memcached server and collectors of the metrics path, the drift baselines and the modules, targets, TLS settings, collectors and limits of the scrape path. Reloaded on SIGHUP and POST /-/reload.").Default("").String()
 		webConfig          = webflag.AddFlags(kingpin.CommandLine, ":9150")
 		metricsPath        = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
 		scrapePath         = kingpin.Flag("web.scrape-path", "Path under which to receive scrape requests.").Default("/scrape").String()
+		statsAPIPath       = kingpin.Flag("web.stats-api-path", "Path under which to return the raw stats of a target as JSON, e.g. /api/v1/stats. Empty disables the stats API.").Default("").String()
+		readyMode          = kingpin.Flag("web.ready.mode", "Report ready on /-/ready if all or any of --memcached.address and the targets of --config.file are reachable.").Default(health.ModeAll).Enum(health.ModeAll, health.ModeAny)
+		readyCacheTTL      = kingpin.Flag("web.ready.cache-ttl", "Reuse the result of a readiness check for this long. 0 checks on every request.").Default("5s").Duration()
 	)
 
 	promslogConfig := &promslog.Config{}
 	flag.AddFlags(kingpin.CommandLine, promslogConfig)
 	kingpin.HelpFlag.Short('h')
 	kingpin.Version(version.Print("memcached_exporter"))
-	kingpin.Parse()
+	command := kingpin.Parse()
 	logger := promslog.New(promslogConfig)
 
-	logger.Info("Starting memcached_exporter", "version", version.Info())
-	logger.Info("Build context", "context", version.BuildContext())
+	// The TLS settings and timeout apply to the target of the probe and top
+	// commands.
+	switch command {
+	case probeCmd.FullCommand():
+		*address = *probeTarget
+	case topCmd.FullCommand():
+		*address = *topTarget
+	}
+
+	if command == serveCmd.FullCommand() {
+		logger.Info("Starting memcached_exporter", "version", version.Info())
+		logger.Info("Build context", "context", version.BuildContext())
+	}
 
 	var (
 		tlsConfig *tls.Config
-		err       error
+		// deriveServerName is set if the TLS server name is taken from the
+		// memcached address.
+		deriveServerName bool
+		err              error
 	)
 	if *enableTLS {
+		deriveServerName = *serverName == ""
 		if *serverName == "" {
 			*serverName, _, err = net.SplitHostPort(*address)
 			if err != nil {
@@ -92,23 +187,267 @@ func main() {
 		}
 	}
 
+	switch command {
+	case probeCmd.FullCommand():
+		os.Exit(probeMain(logger, *address, *timeout, tlsConfig, *enableDerived, *probeFormat, *probeThresholds))
+	case topCmd.FullCommand():
+		if *topInterval <= 0 {
+			logger.Error("The top interval must be positive")
+			os.Exit(2)
+		}
+		os.Exit(topMain(logger, *address, *timeout, tlsConfig, *topInterval))
+	}
+
+	ctx := context.Background()
 	prometheus.MustRegister(versioncollector.NewCollector("memcached_exporter"))
 
-	if *address != "" {
-		prometheus.MustRegister(exporter.New(*address, *timeout, logger, tlsConfig))
+	var pollOpts *exporter.PollOpts
+	if *pollInterval > 0 {
+		pollOpts = &exporter.PollOpts{
+			Interval: *pollInterval,
+			Jitter:   *pollJitter,
+			MaxAge:   *pollMaxAge,
+		}
 	}
 
-	if *pidFile != "" {
-		procExporter := collectors.NewProcessCollector(collectors.ProcessCollectorOpts{
-			PidFn:     prometheus.NewPidFileFn(*pidFile),
-			Namespace: exporter.Namespace,
-		})
-		prometheus.MustRegister(procExporter)
+	var baselines exporter.Baselines
+	if *baselineFile != "" {
+		baselines, err = exporter.LoadBaselines(*baselineFile)
+		if err != nil {
+			logger.Error("Failed to load baselines", "err", err)
+			os.Exit(1)
+		}
 	}
 
-	http.Handle(*metricsPath, promhttp.Handler())
+	// The options of the hot keys, evictions and keyspace collectors are also
+	// used by modules of the configuration file enabling them.
+	if *hotKeysTopK <= 0 || *hotKeysWindow <= 0 || *hotKeysSampleRate <= 0 || *hotKeysSampleRate > 1 {
+		logger.Error("Invalid hot key options, top-k and window must be positive and the sample rate between 0 and 1")
+		os.Exit(1)
+	}
+	hotKeysOpts := watch.HotKeysOpts{
+		TopK:       *hotKeysTopK,
+		Window:     *hotKeysWindow,
+		SampleRate: *hotKeysSampleRate,
+		Mutations:  *hotKeysMutations,
+		KeyOpts: watch.KeyOpts{
+			PrefixDelimiter: *hotKeysDelimiter,
+			PrefixDepth:     *hotKeysDepth,
+			MaxKeyLength:    *hotKeysMaxLength,
+			HashKeys:        *hotKeysHash,
+		},
+	}
+	evictionsOpts := watch.EvictionsOpts{
+		Deletions:   *evictionsDeletions,
+		MaxPrefixes: *evictionsPrefixes,
+		KeyOpts: watch.KeyOpts{
+			PrefixDelimiter: *evictionsDelimiter,
+			PrefixDepth:     *evictionsDepth,
+			MaxKeyLength:    *evictionsMaxLength,
+			HashKeys:        *evictionsHash,
+		},
+	}
+	if *keyspaceInterval <= 0 || *keyspaceMaxTime <= 0 || *keyspaceSampleRate <= 0 || *keyspaceSampleRate > 1 {
+		logger.Error("Invalid keyspace options, interval and max-duration must be positive and the sample rate between 0 and 1")
+		os.Exit(1)
+	}
+	var rules []*regexp.Regexp
+	for _, r := range *keyspaceRules {
+		re, err := regexp.Compile(r)
+		if err != nil {
+			logger.Error("Invalid keyspace prefix rule", "rule", r, "err", err)
+			os.Exit(1)
+		}
+		rules = append(rules, re)
+	}
+	keyspaceOpts := keyspace.Opts{
+		Interval:        *keyspaceInterval,
+		Command:         *keyspaceCommand,
+		SampleRate:      *keyspaceSampleRate,
+		Rules:           rules,
+		Slabs:           *keyspaceSlabs,
+		MaxItems:        *keyspaceMaxItems,
+		MaxDuration:     *keyspaceMaxTime,
+		ItemsPerSecond:  *keyspaceRate,
+		PrefixDelimiter: *keyspaceDelimiter,
+		PrefixDepth:     *keyspaceDepth,
+		MaxPrefixes:     *keyspacePrefixes,
+	}
+
+	// The collectors of the memcached server are kept apart from the
+	// exporter's own metrics, so that OTLP pushes only carry the former. The
+	// metrics endpoint and remote write serve both.
+	memcachedRegistry := prometheus.NewRegistry()
+	gatherer := prometheus.Gatherers{prometheus.DefaultGatherer, memcachedRegistry}
+
+	if *pidFile != "" && (len(*processListen) > 0 || *processName != "") {
+		logger.Error("--memcached.pid-file can't be combined with --memcached.process.listen or --memcached.process.name")
+		os.Exit(1)
+	}
+	// The server of the metrics path and its collectors are selected by the
+	// flags, unless the configuration file has a memcached section.
+	static := &staticTarget{
+		ctx:             ctx,
+		timeout:         *timeout,
+		logger:          logger,
+		tlsConfig:       tlsConfig,
+		serverName:      deriveServerName,
+		constLabels:     *constLabels,
+		pollOpts:        pollOpts,
+		canaryKeyPrefix: *canaryKeyPrefix,
+		hotKeysOpts:     hotKeysOpts,
+		evictionsOpts:   evictionsOpts,
+		keyspaceOpts:    keyspaceOpts,
+		pidFile:         *pidFile,
+		processOpts:     process.Opts{Listen: *processListen, Name: *processName},
+		cgroupRoot:      *cgroupRoot,
+	}
+	staticFlags := staticOpts{
+		address:   *address,
+		derived:   *enableDerived,
+		latency:   *enableLatency,
+		canary:    *enableCanary,
+		meta:      *enableMeta,
+		hotKeys:   *enableHotKeys,
+		evictions: *enableEvictions,
+		keyspace:  *enableKeyspace,
+		osMetrics: *enableOSMetrics,
+		cgroup:    *enableCgroup,
+		baselines: baselines,
+	}
+	memcachedRegistry.MustRegister(static)
+
 	scraper := scraper.New(*timeout, logger, tlsConfig)
+	if pollOpts != nil {
+		scraper.EnablePolling(ctx, *pollOpts)
+	}
+	if *enableDerived {
+		scraper.EnableDerivedMetrics()
+	}
+	if *enableLatency {
+		scraper.EnableLatencyMetrics()
+	}
+	if baselines != nil {
+		scraper.EnableDrift(baselines)
+	}
+	scraper.SetConstLabels(*constLabels)
+	scraper.SetCollectorOpts(hotKeysOpts, evictionsOpts, keyspaceOpts)
+	if *enableCanary {
+		scraper.EnableCanary(*canaryKeyPrefix)
+	}
+	if *enableMeta {
+		scraper.EnableMeta(*canaryKeyPrefix)
+	}
+	if err := scraper.CheckConstLabels(); err != nil {
+		logger.Error("Invalid constant labels", "err", err)
+		os.Exit(1)
+	}
+	if *configFile != "" {
+		// The static target is only replaced once the scraper accepted the
+		// configuration, so an invalid file changes neither.
+		reloader := config.NewReloader(*configFile, logger, func(c *config.Config) error {
+			sc, err := static.build(staticFlags.withConfig(c))
+			if err != nil {
+				return err
+			}
+			if err := scraper.ApplyConfig(c); err != nil {
+				return err
+			}
+			static.apply(sc)
+			return nil
+		})
+		if err := reloader.Reload(); err != nil {
+			logger.Error("Failed to load config", "err", err)
+			os.Exit(1)
+		}
+		prometheus.MustRegister(reloader)
+		reloader.Start(ctx)
+		http.Handle("/-/reload", reloader.Handler())
+	} else {
+		sc, err := static.build(staticFlags)
+		if err != nil {
+			logger.Error("Failed to set up memcached collectors", "err", err)
+			os.Exit(1)
+		}
+		static.apply(sc)
+	}
+
+	if *otlpEndpoint != "" {
+		if static.Address() == "" || *otlpInterval <= 0 {
+			logger.Error("OTLP push requires a memcached address and a positive interval")
+			os.Exit(1)
+		}
+		p := otlp.New(static.Address, *timeout, logger, tlsConfig, memcachedRegistry, otlp.Opts{
+			Endpoint:    *otlpEndpoint,
+			Protocol:    *otlpProtocol,
+			Interval:    *otlpInterval,
+			Headers:     *otlpHeaders,
+			ServiceName: *otlpServiceName,
+		})
+		if err := p.Start(ctx); err != nil {
+			logger.Error("Failed to start OTLP push", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	if *rwURL != "" {
+		if *rwInterval <= 0 || *rwQueueCapacity <= 0 {
+			logger.Error("Invalid remote write options, interval and queue capacity must be positive")
+			os.Exit(1)
+		}
+		labels := map[string]string{"job": "memcached", "instance": static.Address()}
+		for k, v := range *rwLabels {
+			labels[k] = v
+		}
+		httpConfig := promconfig.DefaultHTTPClientConfig
+		if *rwUsername != "" {
+			httpConfig.BasicAuth = &promconfig.BasicAuth{Username: *rwUsername, PasswordFile: *rwPasswordFile}
+		}
+		if *rwBearerTokenFile != "" {
+			httpConfig.Authorization = &promconfig.Authorization{Type: "Bearer", CredentialsFile: *rwBearerTokenFile}
+		}
+		w, err := remotewrite.New(gatherer, logger, remotewrite.Opts{
+			URL:              *rwURL,
+			Interval:         *rwInterval,
+			Timeout:          *rwTimeout,
+			Labels:           labels,
+			QueueCapacity:    *rwQueueCapacity,
+			MaxRetries:       *rwMaxRetries,
+			MinBackoff:       *rwMinBackoff,
+			MaxBackoff:       *rwMaxBackoff,
+			HTTPClientConfig: httpConfig,
+		})
+		if err != nil {
+			logger.Error("Failed to configure remote write", "err", err)
+			os.Exit(1)
+		}
+		prometheus.MustRegister(w)
+		w.Start(ctx)
+	}
+
+	plainHandler := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+	openMetricsHandler := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})
+	http.Handle(*metricsPath, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Exemplars are only exposed in the OpenMetrics format.
+		if static.Latency() {
+			openMetricsHandler.ServeHTTP(w, r)
+			return
+		}
+		plainHandler.ServeHTTP(w, r)
+	})))
 	http.Handle(*scrapePath, scraper.Handler())
+	if *statsAPIPath != "" {
+		http.Handle(*statsAPIPath, scraper.StatsHandler())
+	}
+	readyTargets := func() []health.Target {
+		var targets []health.Target
+		if address := static.Address(); address != "" {
+			targets = append(targets, health.Target{Address: address, Timeout: *timeout, TLSConfig: tlsConfig})
+		}
+		return append(targets, scraper.ReadyTargets()...)
+	}
+	http.Handle("/-/healthy", health.HealthyHandler())
+	http.Handle("/-/ready", health.New(readyTargets, logger, health.Opts{Mode: *readyMode, CacheTTL: *readyCacheTTL}).ReadyHandler())
 
 	if *metricsPath != "/" && *metricsPath != "" {
 		landingConfig := web.LandingConfig{
@@ -136,3 +475,12 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// serverMemoryLimit returns the memory limit of a discovered server, which is
+// only known if it is the server at address.
+func serverMemoryLimit(server, address string, memoryLimit func() float64) func() float64 {
+	if address == "" || !process.Serves(server, address) {
+		return func() float64 { return 0 }
+	}
+	return memoryLimit
+}
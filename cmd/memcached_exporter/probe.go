@@ -0,0 +1,275 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+// Output formats of the probe command.
+const (
+	formatProm  = "prom"
+	formatJSON  = "json"
+	formatTable = "table"
+)
+
+var thresholdRE = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)\s*(<=|>=|<|>)\s*(\S+)$`)
+
+// probeMain runs the probe command against target, writes the metrics to
+// stdout and returns the exit code: 0 if the target is up and no threshold is
+// breached, 1 if it is not and 2 for invalid thresholds.
+func probeMain(logger *slog.Logger, target string, timeout time.Duration, tlsConfig *tls.Config, derived bool, format string, exprs []string) int {
+	var thresholds []threshold
+	for _, expr := range exprs {
+		t, err := parseThreshold(expr)
+		if err != nil {
+			logger.Error("Invalid threshold", "err", err)
+			return 2
+		}
+		thresholds = append(thresholds, t)
+	}
+
+	e := exporter.New(target, timeout, logger, tlsConfig)
+	if derived {
+		e.EnableDerivedMetrics()
+	}
+	r, err := runProbe(target, e, thresholds)
+	if err != nil {
+		logger.Error("Failed to probe memcached", "target", target, "err", err)
+		return 1
+	}
+	if err := r.write(os.Stdout, format); err != nil {
+		logger.Error("Failed to write probe result", "err", err)
+		return 1
+	}
+
+	if !r.Up {
+		logger.Error("Memcached is down", "target", target)
+		return 1
+	}
+	for _, b := range r.Breaches {
+		logger.Error("Threshold breached", "threshold", b)
+	}
+	if len(r.Breaches) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// threshold fails a probe when any sample of metric compares true against
+// value, e.g. "memcached_current_connections>1000".
+type threshold struct {
+	expr   string
+	metric string
+	op     string
+	value  float64
+}
+
+func parseThreshold(s string) (threshold, error) {
+	m := thresholdRE.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return threshold{}, fmt.Errorf("invalid threshold %q, must be <metric><op><value> with op one of <, <=, >, >=", s)
+	}
+	v, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return threshold{}, fmt.Errorf("invalid threshold value in %q: %w", s, err)
+	}
+	return threshold{expr: s, metric: m[1], op: m[2], value: v}, nil
+}
+
+func (t threshold) breached(v float64) bool {
+	switch t.op {
+	case "<":
+		return v < t.value
+	case "<=":
+		return v <= t.value
+	case ">":
+		return v > t.value
+	case ">=":
+		return v >= t.value
+	}
+	return false
+}
+
+// sample is a single value of a gathered metric.
+type sample struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+func (s sample) String() string {
+	var ls []string
+	for _, k := range slices.Sorted(maps.Keys(s.Labels)) {
+		ls = append(ls, k+"="+strconv.Quote(s.Labels[k]))
+	}
+	if len(ls) == 0 {
+		return s.Name
+	}
+	return s.Name + "{" + strings.Join(ls, ",") + "}"
+}
+
+// probeResult is the outcome of a single probe.
+type probeResult struct {
+	Target   string   `json:"target"`
+	Up       bool     `json:"up"`
+	Breaches []string `json:"breaches,omitempty"`
+	Samples  []sample `json:"samples"`
+
+	families []*dto.MetricFamily
+}
+
+// OK reports whether the target was up and no threshold was breached.
+func (r *probeResult) OK() bool {
+	return r.Up && len(r.Breaches) == 0
+}
+
+// runProbe collects the metrics of c once and checks them against the
+// thresholds. A threshold whose metric is missing counts as breached.
+func runProbe(target string, c prometheus.Collector, thresholds []threshold) (*probeResult, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(c); err != nil {
+		return nil, err
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &probeResult{Target: target, families: families, Samples: flatten(families)}
+	for _, s := range r.Samples {
+		if s.Name == "memcached_up" && s.Value == 1 {
+			r.Up = true
+		}
+	}
+	for _, t := range thresholds {
+		found := false
+		for _, s := range r.Samples {
+			if s.Name != t.metric {
+				continue
+			}
+			found = true
+			if t.breached(s.Value) {
+				r.Breaches = append(r.Breaches, fmt.Sprintf("%s: %s is %g", t.expr, s, s.Value))
+			}
+		}
+		if !found {
+			r.Breaches = append(r.Breaches, fmt.Sprintf("%s: metric not found", t.expr))
+		}
+	}
+	return r, nil
+}
+
+// flatten returns the samples of the metric families, expanding summaries and
+// histograms into their series.
+func flatten(families []*dto.MetricFamily) []sample {
+	var samples []sample
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			with := func(k, v string) map[string]string {
+				ls := map[string]string{k: v}
+				for lk, lv := range labels {
+					ls[lk] = lv
+				}
+				return ls
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				samples = append(samples, sample{name, labels, m.GetCounter().GetValue()})
+			case dto.MetricType_GAUGE:
+				samples = append(samples, sample{name, labels, m.GetGauge().GetValue()})
+			case dto.MetricType_UNTYPED:
+				samples = append(samples, sample{name, labels, m.GetUntyped().GetValue()})
+			case dto.MetricType_SUMMARY:
+				for _, q := range m.GetSummary().GetQuantile() {
+					samples = append(samples, sample{name, with("quantile", strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)), q.GetValue()})
+				}
+				samples = append(samples,
+					sample{name + "_sum", labels, m.GetSummary().GetSampleSum()},
+					sample{name + "_count", labels, float64(m.GetSummary().GetSampleCount())},
+				)
+			case dto.MetricType_HISTOGRAM:
+				for _, b := range m.GetHistogram().GetBucket() {
+					le := "+Inf"
+					if !math.IsInf(b.GetUpperBound(), 1) {
+						le = strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)
+					}
+					samples = append(samples, sample{name + "_bucket", with("le", le), float64(b.GetCumulativeCount())})
+				}
+				samples = append(samples,
+					sample{name + "_sum", labels, m.GetHistogram().GetSampleSum()},
+					sample{name + "_count", labels, float64(m.GetHistogram().GetSampleCount())},
+				)
+			}
+		}
+	}
+	return samples
+}
+
+// write prints the result in the given format.
+func (r *probeResult) write(w io.Writer, format string) error {
+	switch format {
+	case formatProm:
+		enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+		for _, mf := range r.families {
+			if err := enc.Encode(mf); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "METRIC\tVALUE")
+		lines := make([]string, 0, len(r.Samples))
+		for _, s := range r.Samples {
+			lines = append(lines, fmt.Sprintf("%s\t%g", s, s.Value))
+		}
+		sort.Strings(lines)
+		for _, l := range lines {
+			fmt.Fprintln(tw, l)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
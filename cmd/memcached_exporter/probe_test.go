@@ -0,0 +1,130 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+func TestParseThreshold(t *testing.T) {
+	th, err := parseThreshold("memcached_current_connections >= 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.metric != "memcached_current_connections" || th.op != ">=" || th.value != 100 {
+		t.Errorf("unexpected threshold %+v", th)
+	}
+	if !th.breached(100) || th.breached(99) {
+		t.Errorf("unexpected comparisons for %+v", th)
+	}
+
+	for _, s := range []string{"memcached_up", "memcached_up=1", "memcached_up<one"} {
+		if _, err := parseThreshold(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestRunProbe(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		return memcachedtest.Stats{
+			Stats: map[string]string{
+				"version":          "1.6.21",
+				"cmd_set":          "3",
+				"cas_hits":         "0",
+				"cas_misses":       "0",
+				"cas_badval":       "0",
+				"curr_connections": "150",
+			},
+			Settings: map[string]string{"maxconns": "1024"},
+		}
+	}))
+	probe := func(exprs ...string) *probeResult {
+		t.Helper()
+		var thresholds []threshold
+		for _, expr := range exprs {
+			th, err := parseThreshold(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			thresholds = append(thresholds, th)
+		}
+		r, err := runProbe(srv.Addr, exporter.New(srv.Addr, time.Second, promslog.NewNopLogger(), nil), thresholds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		r := probe("memcached_current_connections>1000")
+		if !r.OK() {
+			t.Errorf("want a successful probe, got breaches %v", r.Breaches)
+		}
+
+		var buf bytes.Buffer
+		if err := r.write(&buf, formatProm); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "memcached_up 1\n") {
+			t.Errorf("prom output lacks memcached_up: %s", buf.String())
+		}
+
+		buf.Reset()
+		if err := r.write(&buf, formatTable); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), `memcached_max_connections`) {
+			t.Errorf("table output lacks memcached_max_connections: %s", buf.String())
+		}
+
+		buf.Reset()
+		if err := r.write(&buf, formatJSON); err != nil {
+			t.Fatal(err)
+		}
+		var decoded probeResult
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Up || decoded.Target != srv.Addr || len(decoded.Samples) == 0 {
+			t.Errorf("unexpected JSON output %s", buf.String())
+		}
+	})
+
+	t.Run("Threshold breached", func(t *testing.T) {
+		r := probe("memcached_current_connections>100", "memcached_missing>0")
+		if r.OK() || len(r.Breaches) != 2 {
+			t.Errorf("want 2 breaches, got %v", r.Breaches)
+		}
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		r, err := runProbe("127.0.0.1:1", exporter.New("127.0.0.1:1", 100*time.Millisecond, promslog.NewNopLogger(), nil), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Up || r.OK() {
+			t.Error("want a failed probe for an unreachable target")
+		}
+	})
+}
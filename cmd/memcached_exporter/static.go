@@ -0,0 +1,305 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"github.com/prometheus/memcached_exporter/cgroup"
+	"github.com/prometheus/memcached_exporter/config"
+	"github.com/prometheus/memcached_exporter/keyspace"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+	"github.com/prometheus/memcached_exporter/probe"
+	"github.com/prometheus/memcached_exporter/process"
+	"github.com/prometheus/memcached_exporter/watch"
+)
+
+// staticOpts select the memcached server exported on the metrics path and
+// its collectors, from the command line flags or the configuration file.
+type staticOpts struct {
+	address   string
+	derived   bool
+	latency   bool
+	canary    bool
+	meta      bool
+	hotKeys   bool
+	evictions bool
+	keyspace  bool
+	osMetrics bool
+	cgroup    bool
+	baselines exporter.Baselines
+}
+
+// withConfig returns o overridden by the memcached and drift sections of c.
+func (o staticOpts) withConfig(c *config.Config) staticOpts {
+	if b := c.Baselines(); b != nil {
+		o.baselines = b
+	}
+	m := c.Memcached
+	if m == nil {
+		return o
+	}
+	o.address = m.Address
+	if m.Collectors.Derived != nil {
+		o.derived = *m.Collectors.Derived
+	}
+	if m.Collectors.Latency != nil {
+		o.latency = *m.Collectors.Latency
+	}
+	o.canary = m.Collectors.Canary
+	o.meta = m.Collectors.Meta
+	o.hotKeys = m.Collectors.HotKeys
+	o.evictions = m.Collectors.Evictions
+	o.keyspace = m.Collectors.Keyspace
+	o.osMetrics = m.Collectors.OSMetrics
+	o.cgroup = m.Collectors.Cgroup
+	return o
+}
+
+// staticTarget exports the memcached server of the metrics path and its
+// process. Its collectors are replaced when the configuration file is
+// reloaded, so it is an unchecked collector and checks the collectors itself
+// before using them.
+type staticTarget struct {
+	ctx       context.Context
+	timeout   time.Duration
+	logger    *slog.Logger
+	tlsConfig *tls.Config
+	// serverName is set if the TLS server name is taken from the address.
+	serverName      bool
+	constLabels     prometheus.Labels
+	pollOpts        *exporter.PollOpts
+	canaryKeyPrefix string
+	hotKeysOpts     watch.HotKeysOpts
+	evictionsOpts   watch.EvictionsOpts
+	keyspaceOpts    keyspace.Opts
+	pidFile         string
+	processOpts     process.Opts
+	cgroupRoot      string
+
+	mu      sync.RWMutex
+	current *staticCollectors
+}
+
+// staticCollectors are the collectors of one configuration of the static
+// target.
+type staticCollectors struct {
+	address    string
+	latency    bool
+	collectors []prometheus.Collector
+	starts     []func(ctx context.Context)
+	cancel     context.CancelFunc
+}
+
+// build returns the collectors selected by o, checked but not started.
+func (s *staticTarget) build(o staticOpts) (*staticCollectors, error) {
+	findsProcess := s.pidFile != "" || len(s.processOpts.Listen) > 0 || s.processOpts.Name != ""
+	if (o.osMetrics || o.cgroup) && !findsProcess {
+		return nil, errors.New("the OS and cgroup metrics require --memcached.pid-file, --memcached.process.listen or --memcached.process.name")
+	}
+
+	sc := &staticCollectors{address: o.address, latency: o.address != "" && o.latency}
+	registry := prometheus.NewPedanticRegistry()
+	// The exporter adds the constant labels itself, every other collector is
+	// wrapped with them.
+	add := func(c prometheus.Collector, labeled bool) error {
+		if labeled {
+			c = prometheus.WrapCollectorWith(s.constLabels, c)
+		}
+		if err := registry.Register(c); err != nil {
+			return fmt.Errorf("invalid constant labels: %w", err)
+		}
+		sc.collectors = append(sc.collectors, c)
+		return nil
+	}
+
+	// The process collectors take limit_maxbytes from the last scrape rather
+	// than querying memcached themselves.
+	memoryLimit := func() float64 { return 0 }
+	if o.address != "" {
+		tlsConfig, err := s.tlsConfigFor(o.address)
+		if err != nil {
+			return nil, err
+		}
+		e := exporter.NewWithOpts(o.address, s.timeout, s.logger, tlsConfig, exporter.WithConstLabels(s.constLabels))
+		memoryLimit = e.MemoryLimit
+		if o.derived {
+			e.EnableDerivedMetrics()
+		}
+		if o.latency {
+			e.EnableLatencyMetrics()
+		}
+		if b := o.baselines.For("", o.address); b != nil {
+			e.SetBaseline(b)
+		}
+		if err := add(e, false); err != nil {
+			return nil, err
+		}
+		if s.pollOpts != nil {
+			sc.starts = append(sc.starts, func(ctx context.Context) { e.StartPolling(ctx, *s.pollOpts) })
+		}
+
+		if o.canary {
+			if err := add(probe.NewCanary(o.address, s.canaryKeyPrefix, s.timeout, s.logger, tlsConfig, nil), true); err != nil {
+				return nil, err
+			}
+		}
+		if o.meta {
+			if err := add(probe.NewMeta(o.address, s.canaryKeyPrefix, s.timeout, s.logger, tlsConfig, nil), true); err != nil {
+				return nil, err
+			}
+		}
+		if o.hotKeys {
+			h := watch.NewHotKeys(o.address, s.timeout, s.logger, tlsConfig, s.hotKeysOpts, nil)
+			if err := add(h, true); err != nil {
+				return nil, err
+			}
+			sc.starts = append(sc.starts, h.Start)
+		}
+		if o.evictions {
+			ev := watch.NewEvictions(o.address, s.timeout, s.logger, tlsConfig, s.evictionsOpts, nil)
+			if err := add(ev, true); err != nil {
+				return nil, err
+			}
+			sc.starts = append(sc.starts, ev.Start)
+		}
+		if o.keyspace {
+			k := keyspace.New(o.address, s.timeout, s.logger, tlsConfig, s.keyspaceOpts, nil)
+			if err := add(k, true); err != nil {
+				return nil, err
+			}
+			sc.starts = append(sc.starts, k.Start)
+		}
+	}
+
+	if len(s.processOpts.Listen) > 0 || s.processOpts.Name != "" {
+		finder := process.NewFinder(s.processOpts)
+		var perServer []process.NewServerCollector
+		if o.osMetrics {
+			perServer = append(perServer, func(server string, pidFn func() (int, error)) prometheus.Collector {
+				return process.NewOSCollector(pidFn, exporter.Namespace, serverMemoryLimit(server, o.address, memoryLimit), s.logger)
+			})
+		}
+		if o.cgroup {
+			perServer = append(perServer, func(server string, pidFn func() (int, error)) prometheus.Collector {
+				return cgroup.New(pidFn, exporter.Namespace, serverMemoryLimit(server, o.address, memoryLimit), s.logger, cgroup.Opts{CgroupRoot: s.cgroupRoot})
+			})
+		}
+		pc := process.NewCollector(finder, exporter.Namespace, s.logger, perServer...)
+		// The collector is unchecked, so its labels are checked separately.
+		if err := pc.CheckConstLabels(s.constLabels); err != nil {
+			return nil, fmt.Errorf("invalid constant labels: %w", err)
+		}
+		if err := add(pc, true); err != nil {
+			return nil, err
+		}
+	}
+	if s.pidFile != "" {
+		pidFn := prometheus.NewPidFileFn(s.pidFile)
+		if err := add(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{
+			PidFn:     pidFn,
+			Namespace: exporter.Namespace,
+		}), true); err != nil {
+			return nil, err
+		}
+		if o.osMetrics {
+			if err := add(process.NewOSCollector(pidFn, exporter.Namespace, memoryLimit, s.logger), true); err != nil {
+				return nil, err
+			}
+		}
+		if o.cgroup {
+			if err := add(cgroup.New(pidFn, exporter.Namespace, memoryLimit, s.logger, cgroup.Opts{CgroupRoot: s.cgroupRoot}), true); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sc, nil
+}
+
+// tlsConfigFor returns the TLS configuration of connections to address.
+func (s *staticTarget) tlsConfigFor(address string) (*tls.Config, error) {
+	if s.tlsConfig == nil || !s.serverName {
+		return s.tlsConfig, nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("TLS connections to %q require --memcached.tls.server-name: %w", address, err)
+	}
+	tlsConfig := s.tlsConfig.Clone()
+	tlsConfig.ServerName = host
+	return tlsConfig, nil
+}
+
+// apply starts sc and stops the collectors it replaces.
+func (s *staticTarget) apply(sc *staticCollectors) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	sc.cancel = cancel
+	for _, start := range sc.starts {
+		start(ctx)
+	}
+
+	s.mu.Lock()
+	previous := s.current
+	s.current = sc
+	s.mu.Unlock()
+	if previous != nil {
+		previous.cancel()
+	}
+}
+
+// Address returns the address of the memcached server, empty if there is
+// none.
+func (s *staticTarget) Address() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.current == nil {
+		return ""
+	}
+	return s.current.address
+}
+
+// Latency returns whether the latency metrics, whose exemplars require the
+// OpenMetrics format, are enabled.
+func (s *staticTarget) Latency() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current != nil && s.current.latency
+}
+
+// Describe implements prometheus.Collector. The collector is unchecked, as
+// its collectors change on reload.
+func (s *staticTarget) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (s *staticTarget) Collect(ch chan<- prometheus.Metric) {
+	s.mu.RLock()
+	current := s.current
+	s.mu.RUnlock()
+	if current == nil {
+		return
+	}
+	for _, c := range current.collectors {
+		c.Collect(ch)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/config"
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestStaticTarget(t *testing.T) {
+	newServer := func() *memcachedtest.Server {
+		return memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+			return memcachedtest.Stats{
+				Stats:    map[string]string{"version": "1.6.21", "curr_items": "2"},
+				Settings: map[string]string{"maxconns": "1024"},
+			}
+		}))
+	}
+	flagged, configured := newServer(), newServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &staticTarget{
+		ctx:         ctx,
+		timeout:     time.Second,
+		logger:      promslog.NewNopLogger(),
+		constLabels: prometheus.Labels{"pool": "sessions"},
+	}
+	flags := staticOpts{address: flagged.Addr}
+	sc, err := s.build(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.apply(sc)
+	want := `
+# HELP memcached_current_items Current number of items stored by this instance.
+# TYPE memcached_current_items gauge
+memcached_current_items{pool="sessions"} 2
+`
+	if err := testutil.CollectAndCompare(s, strings.NewReader(want), "memcached_current_items"); err != nil {
+		t.Error(err)
+	}
+
+	// The memcached section of the configuration file replaces the flags.
+	latency := true
+	c := &config.Config{Memcached: &config.Memcached{
+		Address: configured.Addr,
+		Collectors: config.MemcachedCollectors{
+			Collectors: config.Collectors{Latency: &latency, Evictions: true},
+		},
+	}}
+	sc, err = s.build(flags.withConfig(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.apply(sc)
+	if got := s.Address(); got != configured.Addr {
+		t.Errorf("want address %s, got %s", configured.Addr, got)
+	}
+	if !s.Latency() {
+		t.Error("latency metrics of the configuration file not enabled")
+	}
+	if n := testutil.CollectAndCount(s, "memcached_item_removals_stream_connected"); n != 1 {
+		t.Errorf("want the evictions collector, got %d series", n)
+	}
+
+	// Invalid configurations are rejected before replacing the collectors.
+	s.constLabels = prometheus.Labels{"reason": "x"}
+	if _, err := s.build(flags.withConfig(c)); err == nil {
+		t.Error("label clashing with the evictions collector was accepted")
+	}
+	c.Memcached.Collectors = config.MemcachedCollectors{OSMetrics: true}
+	if _, err := s.build(flags.withConfig(c)); err == nil {
+		t.Error("OS metrics without a process were accepted")
+	}
+	if got := s.Address(); got != configured.Addr {
+		t.Errorf("want address %s kept, got %s", configured.Addr, got)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grobie/gomemcache/memcache"
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// topRates are the per-second rates of a server between two snapshots.
+type topRates struct {
+	Gets, Sets, Hits, Misses, Evictions float64
+	BytesIn, BytesOut                   float64
+	Connections                         float64
+}
+
+// topSlab is a row of the slab table.
+type topSlab struct {
+	ID                  int
+	ChunkSize           float64
+	Items               float64
+	Pages               float64
+	ChunkUtilization    float64
+	Gets, Sets, Evicted float64
+}
+
+// topView is everything shown on one screen.
+type topView struct {
+	Version            string
+	Uptime             float64
+	CurrentConnections float64
+	CurrentItems       float64
+	Bytes, LimitBytes  float64
+	Rates              topRates
+	Slabs              []topSlab
+}
+
+// topMain shows the rates of target every interval until interrupted.
+func topMain(logger *slog.Logger, target string, timeout time.Duration, tlsConfig *tls.Config, interval time.Duration) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c, err := memcache.New(target)
+	if err != nil {
+		logger.Error("Failed to connect to memcached", "err", err)
+		return 1
+	}
+	c.Timeout = timeout
+	c.TlsConfig = tlsConfig
+
+	var (
+		prev     memcache.Stats
+		prevTime time.Time
+	)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stats, err := serverStats(c)
+		now := time.Now()
+		fmt.Fprint(os.Stdout, clearScreen)
+		if err != nil {
+			fmt.Fprintf(os.Stdout, "%s: %v\n", target, err)
+			prevTime = time.Time{}
+		} else {
+			if !prevTime.IsZero() {
+				renderTop(os.Stdout, target, computeTop(prev, stats, now.Sub(prevTime)))
+			} else {
+				fmt.Fprintf(os.Stdout, "%s: collecting...\n", target)
+			}
+			prev, prevTime = stats, now
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+func serverStats(c *memcache.Client) (memcache.Stats, error) {
+	stats, err := c.Stats()
+	if err != nil {
+		return memcache.Stats{}, err
+	}
+	for _, s := range stats {
+		return s, nil
+	}
+	return memcache.Stats{}, errors.New("no stats returned")
+}
+
+// computeTop computes the view from two snapshots taken elapsed apart.
+// Counters which went backwards, e.g. after a restart, count from zero.
+func computeTop(prev, cur memcache.Stats, elapsed time.Duration) topView {
+	secs := elapsed.Seconds()
+	rate := func(prev, cur map[string]string, key string) float64 {
+		c := value(cur, key)
+		d := c - value(prev, key)
+		if d < 0 {
+			d = c
+		}
+		return d / secs
+	}
+
+	v := topView{
+		Version:            cur.Stats["version"],
+		Uptime:             value(cur.Stats, "uptime"),
+		CurrentConnections: value(cur.Stats, "curr_connections"),
+		CurrentItems:       value(cur.Stats, "curr_items"),
+		Bytes:              value(cur.Stats, "bytes"),
+		LimitBytes:         value(cur.Stats, "limit_maxbytes"),
+		Rates: topRates{
+			Gets:        rate(prev.Stats, cur.Stats, "cmd_get"),
+			Sets:        rate(prev.Stats, cur.Stats, "cmd_set"),
+			Hits:        rate(prev.Stats, cur.Stats, "get_hits"),
+			Misses:      rate(prev.Stats, cur.Stats, "get_misses"),
+			Evictions:   rate(prev.Stats, cur.Stats, "evictions"),
+			BytesIn:     rate(prev.Stats, cur.Stats, "bytes_read"),
+			BytesOut:    rate(prev.Stats, cur.Stats, "bytes_written"),
+			Connections: rate(prev.Stats, cur.Stats, "total_connections"),
+		},
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(cur.Slabs)) {
+		s := cur.Slabs[id]
+		slab := topSlab{
+			ID:        id,
+			ChunkSize: value(s, "chunk_size"),
+			Items:     value(cur.Items[id], "number"),
+			Pages:     value(s, "total_pages"),
+			Gets:      rate(prev.Slabs[id], s, "get_hits"),
+			Sets:      rate(prev.Slabs[id], s, "cmd_set"),
+			Evicted:   rate(prev.Items[id], cur.Items[id], "evicted"),
+		}
+		if total := value(s, "total_chunks"); total > 0 {
+			slab.ChunkUtilization = value(s, "used_chunks") / total
+		}
+		v.Slabs = append(v.Slabs, slab)
+	}
+	return v
+}
+
+// value returns the numeric value of key, or 0 if it is missing or invalid.
+func value(stats map[string]string, key string) float64 {
+	v, err := strconv.ParseFloat(stats[key], 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func renderTop(w io.Writer, target string, v topView) {
+	fmt.Fprintf(w, "%s  memcached %s  up %s\n", target, v.Version, time.Duration(v.Uptime)*time.Second)
+	fmt.Fprintf(w, "items %.0f  memory %s / %s  connections %.0f\n\n",
+		v.CurrentItems, formatBytes(v.Bytes), formatBytes(v.LimitBytes), v.CurrentConnections)
+
+	r := v.Rates
+	hitRatio := "-"
+	if r.Hits+r.Misses > 0 {
+		hitRatio = fmt.Sprintf("%.1f%%", 100*r.Hits/(r.Hits+r.Misses))
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "GET/S\tSET/S\tHIT/S\tMISS/S\tHIT%\tEVICT/S\tIN/S\tOUT/S\tCONN/S\t")
+	fmt.Fprintf(tw, "%.1f\t%.1f\t%.1f\t%.1f\t%s\t%.1f\t%s\t%s\t%.1f\t\n",
+		r.Gets, r.Sets, r.Hits, r.Misses, hitRatio, r.Evictions, formatBytes(r.BytesIn), formatBytes(r.BytesOut), r.Connections)
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "SLAB\tCHUNK\tITEMS\tPAGES\tUSED%\tGET/S\tSET/S\tEVICT/S\t")
+	for _, s := range v.Slabs {
+		fmt.Fprintf(tw, "%d\t%s\t%.0f\t%.0f\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			s.ID, formatBytes(s.ChunkSize), s.Items, s.Pages, 100*s.ChunkUtilization, s.Gets, s.Sets, s.Evicted)
+	}
+	tw.Flush()
+}
+
+// formatBytes formats a number of bytes with a binary unit.
+func formatBytes(b float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for b >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f%s", b, units[i])
+	}
+	return fmt.Sprintf("%.1f%s", b, units[i])
+}
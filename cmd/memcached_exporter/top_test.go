@@ -0,0 +1,66 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grobie/gomemcache/memcache"
+)
+
+func TestComputeTop(t *testing.T) {
+	prev := memcache.Stats{
+		Stats: map[string]string{"cmd_get": "100", "get_hits": "80", "get_misses": "20", "bytes_read": "1000", "evictions": "50"},
+		Slabs: map[int]map[string]string{1: {"get_hits": "10", "cmd_set": "4"}},
+		Items: map[int]map[string]string{1: {"evicted": "5"}},
+	}
+	cur := memcache.Stats{
+		Stats: map[string]string{
+			"version": "1.6.21", "cmd_get": "300", "get_hits": "230", "get_misses": "70",
+			"bytes_read": "3048", "evictions": "10", "curr_connections": "7",
+		},
+		Slabs: map[int]map[string]string{
+			1: {"chunk_size": "96", "total_pages": "2", "total_chunks": "100", "used_chunks": "25", "get_hits": "30", "cmd_set": "8"},
+			5: {"chunk_size": "304", "total_pages": "1"},
+		},
+		Items: map[int]map[string]string{1: {"number": "25", "evicted": "9"}},
+	}
+
+	v := computeTop(prev, cur, 2*time.Second)
+	r := v.Rates
+	if r.Gets != 100 || r.Hits != 75 || r.Misses != 25 || r.BytesIn != 1024 {
+		t.Errorf("unexpected rates %+v", r)
+	}
+	// evictions went backwards, so it counts from zero.
+	if r.Evictions != 5 {
+		t.Errorf("want 5 evictions/s after a reset, got %v", r.Evictions)
+	}
+	if len(v.Slabs) != 2 || v.Slabs[0].ID != 1 || v.Slabs[1].ID != 5 {
+		t.Fatalf("unexpected slabs %+v", v.Slabs)
+	}
+	if s := v.Slabs[0]; s.Gets != 10 || s.Sets != 2 || s.Evicted != 2 || s.Items != 25 || s.ChunkUtilization != 0.25 {
+		t.Errorf("unexpected slab %+v", s)
+	}
+
+	var buf bytes.Buffer
+	renderTop(&buf, "localhost:11211", v)
+	for _, want := range []string{"memcached 1.6.21", "75.0%", "1.0KiB", "304B"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
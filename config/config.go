@@ -0,0 +1,250 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config loads the configuration file of the exporter, which
+// describes the memcached server of the metrics path, the drift baselines and
+// the modules and targets of the multi-target endpoints.
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"go.yaml.in/yaml/v2"
+
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+// Probers a module can use.
+const (
+	ProberDefault = "default"
+	ProberCanary  = "canary"
+	ProberMeta    = "meta"
+)
+
+// Config is the configuration file of the exporter.
+type Config struct {
+	// Memcached is the server exported on the metrics path. If set, it
+	// replaces --memcached.address and the enable flags of its collectors.
+	Memcached *Memcached `yaml:"memcached"`
+	Drift     Drift      `yaml:"drift"`
+	// Modules are selected by the module parameter of scrapes. Modules named
+	// like the built-in default, canary and meta modules replace them.
+	Modules map[string]*Module `yaml:"modules"`
+	// Targets are the known memcached servers.
+	Targets []Target `yaml:"targets"`
+	Limits  Limits   `yaml:"limits"`
+
+	baselines exporter.Baselines
+}
+
+// Memcached is the server exported on the metrics path.
+type Memcached struct {
+	// Address of the server. Empty only exports the metrics of the process.
+	Address    string              `yaml:"address"`
+	Collectors MemcachedCollectors `yaml:"collectors"`
+}
+
+// MemcachedCollectors toggles the optional collectors of the server exported
+// on the metrics path. Unset derived and latency collectors follow their
+// command line flag, the others are off unless enabled. The OS and cgroup
+// collectors require --memcached.pid-file, --memcached.process.listen or
+// --memcached.process.name to find the process.
+type MemcachedCollectors struct {
+	Collectors `yaml:",inline"`
+	Canary     bool `yaml:"canary"`
+	Meta       bool `yaml:"meta"`
+	OSMetrics  bool `yaml:"os_metrics"`
+	Cgroup     bool `yaml:"cgroup"`
+}
+
+// Drift configures the configuration drift metrics.
+type Drift struct {
+	// BaselineFile replaces --memcached.drift.baseline-file. It is read
+	// again on every reload.
+	BaselineFile string `yaml:"baseline_file"`
+}
+
+// Module is a named set of settings used to scrape a target.
+type Module struct {
+	// Prober is one of default, canary or meta.
+	Prober string `yaml:"prober"`
+	// Timeout of connections and commands. Defaults to --memcached.timeout.
+	Timeout model.Duration `yaml:"timeout"`
+	// TLSConfig of connections to memcached. Defaults to the
+	// --memcached.tls.* flags.
+	TLSConfig *promconfig.TLSConfig `yaml:"tls_config"`
+	// KeyPrefix of the keys written by the canary and meta probers.
+	KeyPrefix  string     `yaml:"key_prefix"`
+	Collectors Collectors `yaml:"collectors"`
+
+	tlsConfig *tls.Config
+}
+
+// Collectors toggles optional collectors of the default prober. Unset derived
+// and latency collectors follow their command line flag. The hot keys,
+// evictions and keyspace collectors keep a connection to every target using
+// them and are off unless enabled. Their options are set by the
+// --memcached.hot-keys.*, --memcached.evictions.* and --memcached.keyspace.*
+// flags.
+type Collectors struct {
+	Derived   *bool `yaml:"derived"`
+	Latency   *bool `yaml:"latency"`
+	HotKeys   bool  `yaml:"hot_keys"`
+	Evictions bool  `yaml:"evictions"`
+	Keyspace  bool  `yaml:"keyspace"`
+}
+
+// Target is a known memcached server.
+type Target struct {
+	Address string `yaml:"address"`
+	// Module used when a scrape doesn't ask for one.
+	Module string `yaml:"module"`
+	// Pool selects the drift baseline when a scrape doesn't ask for one.
+	Pool string `yaml:"pool"`
+	// Labels are added to every metric of the target, and override the
+	// global constant labels.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// Limits protect the exporter and memcached from unexpected scrapes.
+type Limits struct {
+	// RestrictTargets rejects scrapes of targets which are not listed.
+	RestrictTargets bool `yaml:"restrict_targets"`
+	// MaxTargets is the maximum number of targets kept by the exporter at a
+	// time, 0 uses the default of 1000.
+	MaxTargets int `yaml:"max_targets"`
+}
+
+// Load reads and validates the configuration file.
+func Load(filename string) (*Config, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(content, c); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", filename, err)
+	}
+	dir := filepath.Dir(filename)
+	for _, m := range c.Modules {
+		if m != nil && m.TLSConfig != nil {
+			m.TLSConfig.SetDirectory(dir)
+		}
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", filename, err)
+	}
+	if c.Drift.BaselineFile != "" {
+		c.Drift.BaselineFile = promconfig.JoinDir(dir, c.Drift.BaselineFile)
+		baselines, err := exporter.LoadBaselines(c.Drift.BaselineFile)
+		if err != nil {
+			return nil, err
+		}
+		c.baselines = baselines
+	}
+	return c, nil
+}
+
+// Baselines returns the baselines of the drift section, or nil if it has no
+// baseline file.
+func (c *Config) Baselines() exporter.Baselines {
+	return c.baselines
+}
+
+func (c *Config) validate() error {
+	if m := c.Memcached; m != nil && m.Address == "" {
+		if cs := m.Collectors; cs.Canary || cs.Meta || cs.HotKeys || cs.Evictions || cs.Keyspace {
+			return fmt.Errorf("memcached: the canary, meta, hot_keys, evictions and keyspace collectors require an address")
+		}
+	}
+	for name, m := range c.Modules {
+		if m == nil {
+			return fmt.Errorf("module %q is empty", name)
+		}
+		switch m.Prober {
+		case "":
+			m.Prober = ProberDefault
+		case ProberDefault, ProberCanary, ProberMeta:
+		default:
+			return fmt.Errorf("module %q: unknown prober %q", name, m.Prober)
+		}
+		if m.Timeout < 0 {
+			return fmt.Errorf("module %q: negative timeout", name)
+		}
+		if m.Prober != ProberDefault && m.KeyPrefix == "" {
+			return fmt.Errorf("module %q: the %s prober requires a key_prefix", name, m.Prober)
+		}
+		if c := m.Collectors; m.Prober != ProberDefault && (c.HotKeys || c.Evictions || c.Keyspace) {
+			return fmt.Errorf("module %q: the hot_keys, evictions and keyspace collectors require the %s prober", name, ProberDefault)
+		}
+		if m.TLSConfig != nil {
+			tlsConfig, err := promconfig.NewTLSConfig(m.TLSConfig)
+			if err != nil {
+				return fmt.Errorf("module %q: %w", name, err)
+			}
+			m.tlsConfig = tlsConfig
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, t := range c.Targets {
+		if t.Address == "" {
+			return fmt.Errorf("target without address")
+		}
+		if seen[t.Address] {
+			return fmt.Errorf("duplicate target %q", t.Address)
+		}
+		seen[t.Address] = true
+		if _, ok := c.Modules[t.Module]; t.Module != "" && !ok && !builtin(t.Module) {
+			return fmt.Errorf("target %q: unknown module %q", t.Address, t.Module)
+		}
+	}
+	if c.Limits.MaxTargets < 0 {
+		return fmt.Errorf("negative max_targets")
+	}
+	return nil
+}
+
+func builtin(module string) bool {
+	return module == ProberDefault || module == ProberCanary || module == ProberMeta
+}
+
+// Target returns the configuration of a listed target.
+func (c *Config) Target(address string) (Target, bool) {
+	for _, t := range c.Targets {
+		if t.Address == address {
+			return t, true
+		}
+	}
+	return Target{}, false
+}
+
+// TLS returns the TLS configuration of the module, or nil if it doesn't use
+// TLS.
+func (m *Module) TLS() *tls.Config {
+	return m.tlsConfig
+}
+
+// TimeoutOr returns the timeout of the module, or def if it has none.
+func (m *Module) TimeoutOr(def time.Duration) time.Duration {
+	if m.Timeout == 0 {
+		return def
+	}
+	return time.Duration(m.Timeout)
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		c, err := Load(writeConfig(t, `
+modules:
+  sessions:
+    timeout: 500ms
+    tls_config:
+      insecure_skip_verify: true
+    collectors:
+      derived: true
+      hot_keys: true
+      keyspace: true
+  writes:
+    prober: canary
+    key_prefix: "exporter:"
+targets:
+  - address: cache-1:11211
+    module: sessions
+    pool: sessions
+    labels:
+      shard: "1"
+  - address: cache-2:11211
+limits:
+  restrict_targets: true
+  max_targets: 10
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sessions := c.Modules["sessions"]
+		if sessions.Prober != ProberDefault {
+			t.Errorf("want default prober, got %q", sessions.Prober)
+		}
+		if got := sessions.TimeoutOr(time.Second); got != 500*time.Millisecond {
+			t.Errorf("want timeout 500ms, got %s", got)
+		}
+		if tlsConfig := sessions.TLS(); tlsConfig == nil || !tlsConfig.InsecureSkipVerify {
+			t.Errorf("unexpected TLS config %v", tlsConfig)
+		}
+		if d := sessions.Collectors.Derived; d == nil || !*d {
+			t.Error("derived collector not enabled")
+		}
+		if c := sessions.Collectors; !c.HotKeys || c.Evictions || !c.Keyspace {
+			t.Errorf("unexpected collectors %+v", c)
+		}
+		if got := c.Modules["writes"].TimeoutOr(time.Second); got != time.Second {
+			t.Errorf("want default timeout 1s, got %s", got)
+		}
+		if c.Modules["writes"].TLS() != nil {
+			t.Error("unexpected TLS config")
+		}
+
+		target, ok := c.Target("cache-1:11211")
+		if !ok || target.Module != "sessions" || target.Pool != "sessions" || target.Labels["shard"] != "1" {
+			t.Errorf("unexpected target %+v", target)
+		}
+		if _, ok := c.Target("cache-3:11211"); ok {
+			t.Error("unlisted target found")
+		}
+		if !c.Limits.RestrictTargets || c.Limits.MaxTargets != 10 {
+			t.Errorf("unexpected limits %+v", c.Limits)
+		}
+	})
+
+	t.Run("Memcached and drift", func(t *testing.T) {
+		filename := writeConfig(t, `
+memcached:
+  address: localhost:11211
+  collectors:
+    latency: true
+    evictions: true
+    os_metrics: true
+drift:
+  baseline_file: baselines.yml
+`)
+		baselines := "pools:\n  default:\n    settings:\n      maxconns: 1024\n"
+		if err := os.WriteFile(filepath.Join(filepath.Dir(filename), "baselines.yml"), []byte(baselines), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c, err := Load(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := c.Memcached
+		if m == nil || m.Address != "localhost:11211" {
+			t.Fatalf("unexpected memcached section %+v", m)
+		}
+		if cs := m.Collectors; cs.Derived != nil || cs.Latency == nil || !*cs.Latency || !cs.Evictions || cs.HotKeys || !cs.OSMetrics || cs.Cgroup {
+			t.Errorf("unexpected collectors %+v", cs)
+		}
+		if b := c.Baselines().For("", "localhost:11211"); b == nil || b.Settings["maxconns"] != "1024" {
+			t.Errorf("unexpected baseline %+v", b)
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		content string
+		err     string
+	}{
+		"Unknown field": {
+			content: "targets:\n  - addr: cache-1:11211\n",
+			err:     "field addr not found",
+		},
+		"Unknown prober": {
+			content: "modules:\n  foo:\n    prober: bar\n",
+			err:     `unknown prober "bar"`,
+		},
+		"Canary without key prefix": {
+			content: "modules:\n  foo:\n    prober: canary\n",
+			err:     "requires a key_prefix",
+		},
+		"Hot keys without default prober": {
+			content: "modules:\n  foo:\n    prober: meta\n    key_prefix: x\n    collectors:\n      hot_keys: true\n",
+			err:     "require the default prober",
+		},
+		"Missing CA file": {
+			content: "modules:\n  foo:\n    tls_config:\n      ca_file: missing.pem\n",
+			err:     "missing.pem",
+		},
+		"Duplicate target": {
+			content: "targets:\n  - address: a:1\n  - address: a:1\n",
+			err:     `duplicate target "a:1"`,
+		},
+		"Unknown module": {
+			content: "targets:\n  - address: a:1\n    module: foo\n",
+			err:     `unknown module "foo"`,
+		},
+		"Memcached collectors without address": {
+			content: "memcached:\n  collectors:\n    hot_keys: true\n",
+			err:     "require an address",
+		},
+		"Missing baseline file": {
+			content: "drift:\n  baseline_file: missing.yml\n",
+			err:     "missing.yml",
+		},
+		"Negative max targets": {
+			content: "limits:\n  max_targets: -1\n",
+			err:     "negative max_targets",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tc.content))
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("want error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
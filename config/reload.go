@@ -0,0 +1,141 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reloader reloads the configuration file on SIGHUP and on POST requests to
+// its handler, and passes every valid configuration to apply.
+type Reloader struct {
+	filename string
+	logger   *slog.Logger
+	apply    func(*Config) error
+
+	mu          sync.Mutex
+	successful  bool
+	lastSuccess time.Time
+
+	successfulDesc  *prometheus.Desc
+	lastSuccessDesc *prometheus.Desc
+}
+
+// NewReloader returns a Reloader of filename.
+func NewReloader(filename string, logger *slog.Logger, apply func(*Config) error) *Reloader {
+	return &Reloader{
+		filename: filename,
+		logger:   logger,
+		apply:    apply,
+		successfulDesc: prometheus.NewDesc(
+			"memcached_exporter_config_last_reload_successful",
+			"Whether the last configuration reload attempt was successful.",
+			nil, nil,
+		),
+		lastSuccessDesc: prometheus.NewDesc(
+			"memcached_exporter_config_last_reload_success_timestamp_seconds",
+			"Timestamp of the last successful configuration reload.",
+			nil, nil,
+		),
+	}
+}
+
+// Reload loads the configuration file and applies it. A configuration which
+// fails to load or apply leaves the previous one in place.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := Load(r.filename)
+	if err == nil {
+		err = r.apply(c)
+	}
+	if err != nil {
+		r.successful = false
+		return err
+	}
+	r.successful = true
+	r.lastSuccess = time.Now()
+	return nil
+}
+
+// Start reloads the configuration on SIGHUP until ctx is done.
+func (r *Reloader) Start(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if err := r.Reload(); err != nil {
+					r.logger.Error("Error reloading config", "err", err)
+					continue
+				}
+				r.logger.Info("Reloaded config", "file", r.filename)
+			}
+		}
+	}()
+}
+
+// Handler reloads the configuration on POST requests.
+func (r *Reloader) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.Reload(); err != nil {
+			r.logger.Error("Error reloading config", "err", err)
+			http.Error(w, "failed to reload config: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		r.logger.Info("Reloaded config", "file", r.filename)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (r *Reloader) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.successfulDesc
+	ch <- r.lastSuccessDesc
+}
+
+// Collect implements prometheus.Collector.
+func (r *Reloader) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	successful := 0.0
+	if r.successful {
+		successful = 1
+	}
+	ch <- prometheus.MustNewConstMetric(r.successfulDesc, prometheus.GaugeValue, successful)
+	lastSuccess := 0.0
+	if !r.lastSuccess.IsZero() {
+		lastSuccess = float64(r.lastSuccess.UnixNano()) / 1e9
+	}
+	ch <- prometheus.MustNewConstMetric(r.lastSuccessDesc, prometheus.GaugeValue, lastSuccess)
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+)
+
+func TestReloader(t *testing.T) {
+	filename := writeConfig(t, "targets:\n  - address: cache-1:11211\n")
+
+	var applied *Config
+	r := NewReloader(filename, promslog.NewNopLogger(), func(c *Config) error {
+		applied = c
+		return nil
+	})
+
+	reload := func(method string) int {
+		rr := httptest.NewRecorder()
+		r.Handler().ServeHTTP(rr, httptest.NewRequest(method, "/-/reload", nil))
+		return rr.Code
+	}
+
+	if code := reload(http.MethodGet); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET returned status %d", code)
+	}
+	if applied != nil {
+		t.Error("GET reloaded the config")
+	}
+
+	if code := reload(http.MethodPost); code != http.StatusOK {
+		t.Errorf("reload returned status %d", code)
+	}
+	if applied == nil || len(applied.Targets) != 1 {
+		t.Fatalf("config not applied: %+v", applied)
+	}
+	if !r.successful || r.lastSuccess.IsZero() {
+		t.Error("successful reload not recorded")
+	}
+
+	if err := os.WriteFile(filename, []byte("targets: [{addr: foo}]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previous := applied
+	if code := reload(http.MethodPost); code != http.StatusInternalServerError {
+		t.Errorf("invalid config returned status %d", code)
+	}
+	if applied != previous {
+		t.Error("invalid config was applied")
+	}
+	want := `
+# HELP memcached_exporter_config_last_reload_successful Whether the last configuration reload attempt was successful.
+# TYPE memcached_exporter_config_last_reload_successful gauge
+memcached_exporter_config_last_reload_successful 0
+`
+	if err := testutil.CollectAndCompare(r, strings.NewReader(want), "memcached_exporter_config_last_reload_successful"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReloadCollectors(t *testing.T) {
+	filename := writeConfig(t, "modules:\n  watched:\n    collectors:\n      hot_keys: true\n")
+
+	var applied *Config
+	r := NewReloader(filename, promslog.NewNopLogger(), func(c *Config) error {
+		applied = c
+		return nil
+	})
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if c := applied.Modules["watched"].Collectors; !c.HotKeys || c.Evictions || c.Keyspace {
+		t.Errorf("unexpected collectors %+v", c)
+	}
+
+	content := "modules:\n  watched:\n    collectors:\n      evictions: true\n      keyspace: true\n"
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if c := applied.Modules["watched"].Collectors; c.HotKeys || !c.Evictions || !c.Keyspace {
+		t.Errorf("unexpected collectors after reload %+v", c)
+	}
+
+	// Stream collectors of other probers are rejected, keeping the previous
+	// configuration.
+	previous := applied
+	content = "modules:\n  watched:\n    prober: canary\n    key_prefix: x\n    collectors:\n      keyspace: true\n"
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Error("invalid collectors were accepted")
+	}
+	if applied != previous {
+		t.Error("invalid config was applied")
+	}
+}
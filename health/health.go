@@ -0,0 +1,158 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package health implements the /-/healthy and /-/ready endpoints. The
+// exporter is ready once it can reach its memcached targets.
+package health
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/memcached_exporter/client"
+)
+
+const (
+	// ModeAll requires every target to be reachable.
+	ModeAll = "all"
+	// ModeAny requires at least one target to be reachable.
+	ModeAny = "any"
+)
+
+// Target is a memcached server checked for readiness.
+type Target struct {
+	Address   string
+	Timeout   time.Duration
+	TLSConfig *tls.Config
+}
+
+// Opts configure the readiness check.
+type Opts struct {
+	// Mode is ModeAll or ModeAny, ModeAll by default.
+	Mode string
+	// CacheTTL is how long the result of a check is reused. 0 checks the
+	// targets on every request.
+	CacheTTL time.Duration
+}
+
+// result is the outcome of checking a single target.
+type result struct {
+	address string
+	err     error
+}
+
+// Checker checks whether the targets returned by targets are reachable.
+// Concurrent requests share a single check.
+type Checker struct {
+	targets func() []Target
+	opts    Opts
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	checked time.Time
+	ready   bool
+	results []result
+}
+
+// New returns a Checker of the targets returned by targets. They are looked
+// up on every check, so targets of a reloaded configuration are picked up.
+func New(targets func() []Target, logger *slog.Logger, opts Opts) *Checker {
+	if opts.Mode == "" {
+		opts.Mode = ModeAll
+	}
+	return &Checker{targets: targets, opts: opts, logger: logger}
+}
+
+// check reports whether the targets are reachable according to the mode.
+// Without any targets the exporter is always ready.
+func (c *Checker) check() (bool, []result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checked.IsZero() && time.Since(c.checked) < c.opts.CacheTTL {
+		return c.ready, c.results
+	}
+
+	targets := c.targets()
+	results := make([]result, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Go(func() {
+			results[i] = result{address: t.Address, err: ping(t)}
+		})
+	}
+	wg.Wait()
+
+	up := 0
+	for _, r := range results {
+		if r.err == nil {
+			up++
+		} else {
+			c.logger.Debug("Memcached target not reachable", "target", r.address, "err", r.err)
+		}
+	}
+	ready := len(results) == 0 || up == len(results)
+	if c.opts.Mode == ModeAny {
+		ready = len(results) == 0 || up > 0
+	}
+
+	c.checked, c.ready, c.results = time.Now(), ready, results
+	return ready, results
+}
+
+// ping connects to the target and runs the version command.
+func ping(t Target) error {
+	conn, err := client.Dial(t.Address, t.Timeout, t.TLSConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Version()
+	return err
+}
+
+// HealthyHandler always succeeds while the exporter is able to serve
+// requests.
+func HealthyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "memcached_exporter is Healthy.")
+	}
+}
+
+// ReadyHandler returns 200 if the targets are reachable and 503 otherwise,
+// listing the state of every target.
+func (c *Checker) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		ready, results := c.check()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if ready {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, "memcached_exporter is Ready.")
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, "memcached_exporter is not Ready.")
+		}
+		for _, r := range results {
+			if r.err != nil {
+				fmt.Fprintf(w, "%s: %s\n", r.address, r.err)
+			} else {
+				fmt.Fprintf(w, "%s: up\n", r.address)
+			}
+		}
+	}
+}
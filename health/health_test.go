@@ -0,0 +1,112 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestReadyHandler(t *testing.T) {
+	var versions atomic.Int64
+	up := memcachedtest.NewServer(t, func(w *bufio.Writer, _ *bufio.Reader, line string) bool {
+		if line == "version" {
+			versions.Add(1)
+			w.WriteString("VERSION 1.6.21\r\n")
+		} else {
+			w.WriteString("ERROR\r\n")
+		}
+		return true
+	})
+
+	// A closed listener gives an address which refuses connections.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := ln.Addr().String()
+	ln.Close()
+
+	ready := func(c *Checker) (int, string) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		c.ReadyHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/-/ready", nil))
+		return rr.Code, rr.Body.String()
+	}
+	targets := func(addrs ...string) func() []Target {
+		return func() []Target {
+			var ts []Target
+			for _, a := range addrs {
+				ts = append(ts, Target{Address: a, Timeout: time.Second})
+			}
+			return ts
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		mode    string
+		targets []string
+		want    int
+	}{
+		{"All up", ModeAll, []string{up.Addr}, http.StatusOK},
+		{"All down", ModeAll, []string{up.Addr, down}, http.StatusServiceUnavailable},
+		{"Any up", ModeAny, []string{up.Addr, down}, http.StatusOK},
+		{"Any down", ModeAny, []string{down}, http.StatusServiceUnavailable},
+		{"No targets", ModeAll, nil, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			code, body := ready(New(targets(tc.targets...), promslog.NewNopLogger(), Opts{Mode: tc.mode}))
+			if code != tc.want {
+				t.Errorf("want status %d, got %d: %s", tc.want, code, body)
+			}
+			for _, addr := range tc.targets {
+				if !strings.Contains(body, addr+": ") {
+					t.Errorf("want state of %s in body, got %q", addr, body)
+				}
+			}
+		})
+	}
+
+	t.Run("Cache", func(t *testing.T) {
+		c := New(targets(up.Addr), promslog.NewNopLogger(), Opts{CacheTTL: time.Hour})
+		before := versions.Load()
+		for range 3 {
+			if code, body := ready(c); code != http.StatusOK {
+				t.Fatalf("want status 200, got %d: %s", code, body)
+			}
+		}
+		if n := versions.Load() - before; n != 1 {
+			t.Errorf("want a single check within the cache TTL, got %d", n)
+		}
+	})
+}
+
+func TestHealthyHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	HealthyHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/-/healthy", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("want status 200, got %d", rr.Code)
+	}
+}
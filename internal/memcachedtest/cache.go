@@ -0,0 +1,239 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memcachedtest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type entry struct {
+	value []byte
+	flags uint32
+	ttl   int
+	cas   uint64
+}
+
+// Cache is an in-memory key value store answering the text and meta protocol
+// storage commands.
+type Cache struct {
+	mu      sync.Mutex
+	items   map[string]entry
+	nextCAS uint64
+}
+
+// NewCache returns an empty cache.
+func NewCache() *Cache {
+	return &Cache{items: map[string]entry{}}
+}
+
+// Len returns the number of stored items.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Handler answers set, cas, get, gets and delete as well as the meta commands
+// ms, mg, md and mn, and falls back to next for every other command.
+func (c *Cache) Handler(next HandlerFunc) HandlerFunc {
+	return func(w *bufio.Writer, r *bufio.Reader, line string) bool {
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			return next(w, r, line)
+		}
+		switch f[0] {
+		case "set", "cas":
+			return c.store(w, r, f)
+		case "get", "gets":
+			c.get(w, f[1:])
+			return true
+		case "delete":
+			c.delete(w, f[1:])
+			return true
+		case "ms":
+			return c.metaSet(w, r, f[1:])
+		case "mg":
+			c.metaGet(w, f[1:])
+			return true
+		case "md":
+			c.metaDelete(w, f[1:])
+			return true
+		case "mn":
+			w.WriteString("MN\r\n")
+			return true
+		}
+		return next(w, r, line)
+	}
+}
+
+func (c *Cache) store(w *bufio.Writer, r *bufio.Reader, f []string) bool {
+	if len(f) < 5 {
+		w.WriteString("ERROR\r\n")
+		return true
+	}
+	flags, _ := strconv.ParseUint(f[2], 10, 32)
+	size, err := strconv.Atoi(f[4])
+	if err != nil {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return false
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f[0] == "cas" {
+		cur, ok := c.items[f[1]]
+		if !ok {
+			w.WriteString("NOT_FOUND\r\n")
+			return true
+		}
+		if len(f) < 6 || f[5] != strconv.FormatUint(cur.cas, 10) {
+			w.WriteString("EXISTS\r\n")
+			return true
+		}
+	}
+	ttl, _ := strconv.Atoi(f[3])
+	c.set(f[1], data[:size], uint32(flags), ttl)
+	w.WriteString("STORED\r\n")
+	return true
+}
+
+func (c *Cache) set(key string, value []byte, flags uint32, ttl int) {
+	c.nextCAS++
+	c.items[key] = entry{value: value, flags: flags, ttl: ttl, cas: c.nextCAS}
+}
+
+func (c *Cache) get(w *bufio.Writer, keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range keys {
+		if e, ok := c.items[k]; ok {
+			fmt.Fprintf(w, "VALUE %s %d %d %d\r\n%s\r\n", k, e.flags, len(e.value), e.cas, e.value)
+		}
+	}
+	w.WriteString("END\r\n")
+}
+
+func (c *Cache) delete(w *bufio.Writer, args []string) {
+	if len(args) == 0 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[args[0]]; !ok {
+		w.WriteString("NOT_FOUND\r\n")
+		return
+	}
+	delete(c.items, args[0])
+	w.WriteString("DELETED\r\n")
+}
+
+// metaFlags returns the flags of a meta command keyed by their letter.
+func metaFlags(args []string) map[byte]string {
+	flags := map[byte]string{}
+	for _, a := range args {
+		if a != "" {
+			flags[a[0]] = a[1:]
+		}
+	}
+	return flags
+}
+
+func (c *Cache) metaSet(w *bufio.Writer, r *bufio.Reader, args []string) bool {
+	if len(args) < 2 {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return true
+	}
+	size, err := strconv.Atoi(args[1])
+	if err != nil {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return false
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return false
+	}
+	flags := metaFlags(args[2:])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[args[0]]; ok && flags['M'] == "E" {
+		w.WriteString("NS\r\n")
+		return true
+	}
+	clientFlags, _ := strconv.ParseUint(flags['F'], 10, 32)
+	ttl, _ := strconv.Atoi(flags['T'])
+	c.set(args[0], data[:size], uint32(clientFlags), ttl)
+	w.WriteString("HD\r\n")
+	return true
+}
+
+func (c *Cache) metaGet(w *bufio.Writer, args []string) {
+	if len(args) == 0 {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[args[0]]
+	if !ok {
+		w.WriteString("EN\r\n")
+		return
+	}
+
+	var ret []string
+	flags := metaFlags(args[1:])
+	if _, ok := flags['t']; ok {
+		ttl := e.ttl
+		if ttl == 0 {
+			ttl = -1
+		}
+		ret = append(ret, "t"+strconv.Itoa(ttl))
+	}
+	if _, ok := flags['c']; ok {
+		ret = append(ret, "c"+strconv.FormatUint(e.cas, 10))
+	}
+	if _, ok := flags['f']; ok {
+		ret = append(ret, "f"+strconv.FormatUint(uint64(e.flags), 10))
+	}
+	if _, ok := flags['v']; !ok {
+		w.WriteString(strings.Join(append([]string{"HD"}, ret...), " ") + "\r\n")
+		return
+	}
+	fmt.Fprintf(w, "%s\r\n%s\r\n", strings.Join(append([]string{"VA", strconv.Itoa(len(e.value))}, ret...), " "), e.value)
+}
+
+func (c *Cache) metaDelete(w *bufio.Writer, args []string) {
+	if len(args) == 0 {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[args[0]]; !ok {
+		w.WriteString("NF\r\n")
+		return
+	}
+	delete(c.items, args[0])
+	w.WriteString("HD\r\n")
+}
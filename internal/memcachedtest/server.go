@@ -0,0 +1,153 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memcachedtest provides a fake memcached server for tests.
+package memcachedtest
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// HandlerFunc answers a single command line sent by a client. The line is
+// passed without its trailing "\r\n". Data blocks following storage commands
+// can be read from r. Returning false closes the connection.
+type HandlerFunc func(w *bufio.Writer, r *bufio.Reader, line string) bool
+
+// Server is a fake memcached server listening on a local TCP port.
+type Server struct {
+	Addr string
+
+	ln      net.Listener
+	handler HandlerFunc
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewServer starts a fake memcached server using the given handler. The
+// server is closed when the test finishes.
+func NewServer(t testing.TB, handler HandlerFunc) *Server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		Addr:    ln.Addr().String(),
+		ln:      ln,
+		handler: handler,
+		conns:   map[net.Conn]struct{}{},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close stops the server and closes all open client connections.
+func (s *Server) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		ok := s.handler(w, r, strings.TrimRight(line, "\r\n"))
+		if err := w.Flush(); err != nil || !ok {
+			return
+		}
+	}
+}
+
+// Stats holds the canned responses served by StatsHandler.
+type Stats struct {
+	Stats    map[string]string
+	Slabs    map[string]string
+	Items    map[string]string
+	Settings map[string]string
+	Sizes    map[string]string
+}
+
+// StatsHandler returns a handler answering the "stats" family of commands
+// with the given canned values. Unknown commands get an ERROR response.
+func StatsHandler(stats func() Stats) HandlerFunc {
+	return func(w *bufio.Writer, _ *bufio.Reader, line string) bool {
+		s := stats()
+		switch line {
+		case "stats":
+			WriteStats(w, s.Stats)
+		case "stats slabs":
+			WriteStats(w, s.Slabs)
+		case "stats items":
+			WriteStats(w, s.Items)
+		case "stats settings":
+			WriteStats(w, s.Settings)
+		case "stats sizes":
+			WriteStats(w, s.Sizes)
+		default:
+			w.WriteString("ERROR\r\n")
+		}
+		return true
+	}
+}
+
+// WriteStats writes the given values as a STAT response terminated by END.
+func WriteStats(w *bufio.Writer, values map[string]string) {
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		fmt.Fprintf(w, "STAT %s %s\r\n", k, values[k])
+	}
+	w.WriteString("END\r\n")
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyspace
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/memcached_exporter/client"
+)
+
+// errBusy is returned when the LRU crawler is already running.
+var errBusy = errors.New("lru crawler is busy")
+
+// item is a single line of "lru_crawler metadump" output, e.g.
+// "key=foo exp=1700000600 la=1700000000 cas=5 fetch=yes cls=1 size=64".
+type item struct {
+	key     string
+	exp     int64 // Absolute expiry, -1 if the item never expires.
+	la      int64 // Absolute time of the last access.
+	fetched bool
+	class   int
+	size    int64
+}
+
+// parseItem parses a metadump line. It returns an error for lines which are
+// not items, including the BUSY response of a crawler already running.
+func parseItem(line string) (item, error) {
+	if strings.HasPrefix(line, "BUSY") {
+		return item{}, fmt.Errorf("%w: %s", errBusy, line)
+	}
+	it := item{exp: -1}
+	var hasKey bool
+	for _, field := range strings.Fields(line) {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		var err error
+		switch k {
+		case "key":
+			// memcached URI encodes keys in dumps. Keys are binary safe, so
+			// bytes which are not valid UTF-8 are replaced to keep prefixes
+			// usable as label values.
+			it.key, err = url.PathUnescape(v)
+			it.key = strings.ToValidUTF8(it.key, "\uFFFD")
+			hasKey = true
+		case "exp":
+			it.exp, err = strconv.ParseInt(v, 10, 64)
+		case "la":
+			it.la, err = strconv.ParseInt(v, 10, 64)
+		case "fetch":
+			it.fetched = v == "yes"
+		case "cls":
+			it.class, err = strconv.Atoi(v)
+		case "size":
+			it.size, err = strconv.ParseInt(v, 10, 64)
+		}
+		if err != nil {
+			return item{}, fmt.Errorf("invalid field %q in metadump line: %w", field, err)
+		}
+	}
+	if !hasKey {
+		return item{}, fmt.Errorf("unexpected metadump line %q", line)
+	}
+	return it, nil
+}
+
+// dumpedKey is a single line of "lru_crawler mgdump" output, e.g. "mg foo",
+// or "mg Zm9v b" for binary keys.
+type dumpedKey struct {
+	key    string
+	arg    string // The key as sent back in mg requests.
+	binary bool
+}
+
+func parseDumpedKey(line string) (dumpedKey, error) {
+	if strings.HasPrefix(line, "BUSY") {
+		return dumpedKey{}, fmt.Errorf("%w: %s", errBusy, line)
+	}
+	f := strings.Fields(line)
+	if len(f) < 2 || f[0] != "mg" {
+		return dumpedKey{}, fmt.Errorf("unexpected mgdump line %q", line)
+	}
+	dk := dumpedKey{key: f[1], arg: f[1], binary: len(f) > 2 && f[2] == "b"}
+	if dk.binary {
+		k, err := base64.StdEncoding.DecodeString(f[1])
+		if err != nil {
+			return dumpedKey{}, fmt.Errorf("invalid binary key in mgdump line %q: %w", line, err)
+		}
+		// Binary keys are rarely valid UTF-8, replace what isn't to keep
+		// prefixes usable as label values.
+		dk.key = strings.ToValidUTF8(string(k), "\uFFFD")
+	}
+	return dk, nil
+}
+
+// fetch returns the metadata of the key with mg. The u flag leaves the LRU
+// position and the fetched bit of the item alone, so measuring doesn't change
+// what gets evicted. It reports false if the item is gone.
+func (dk dumpedKey) fetch(conn *client.Conn, now int64) (item, bool, error) {
+	line := "mg " + dk.arg + " s t l h u"
+	if dk.binary {
+		line += " b"
+	}
+	resp, err := conn.Command(line)
+	if err != nil {
+		return item{}, false, err
+	}
+	if resp == "EN" {
+		return item{}, false, nil
+	}
+	f := strings.Fields(resp)
+	if len(f) == 0 || f[0] != "HD" {
+		return item{}, false, fmt.Errorf("unexpected response %q to %q", resp, line)
+	}
+
+	it := item{key: dk.key, exp: -1, la: now}
+	for _, flag := range f[1:] {
+		v, err := strconv.ParseInt(flag[1:], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch flag[0] {
+		case 's':
+			it.size = v
+		case 't':
+			if v >= 0 {
+				it.exp = now + v
+			}
+		case 'l':
+			it.la = now - v
+		case 'h':
+			it.fetched = v == 1
+		}
+	}
+	return it, true, nil
+}
+
+// histogram accumulates the observations of a single dump.
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// buckets returns the cumulative bucket counts.
+func (h *histogram) buckets() map[float64]uint64 {
+	r := make(map[float64]uint64, len(h.bounds))
+	var c uint64
+	for i, b := range h.bounds {
+		c += h.counts[i]
+		r[b] = c
+	}
+	return r
+}
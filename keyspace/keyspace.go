@@ -0,0 +1,541 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keyspace implements a collector describing the items stored in
+// memcached by dumping them with the LRU crawler.
+package keyspace
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/client"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+const (
+	subsystem = "keyspace"
+
+	// otherPrefix is reported for keys beyond the key prefix limit.
+	otherPrefix = "_other"
+
+	// pacingBatch is the number of items read between rate limit checks.
+	pacingBatch = 100
+
+	// CommandMetadump dumps keys with their metadata.
+	CommandMetadump = "metadump"
+	// CommandMgdump dumps keys only. The metadata of sampled keys is then
+	// fetched with mg, which keeps the crawler cheap when sampling.
+	CommandMgdump = "mgdump"
+
+	// labelPrefix is the label every rule sets, from a capture group of the
+	// same name or from the whole match.
+	labelPrefix = "prefix"
+)
+
+var (
+	// ageBuckets spans one second to roughly seven weeks.
+	ageBuckets = prometheus.ExponentialBuckets(1, 4, 12)
+	// sizeBuckets spans 64 bytes to the default 1MiB item size limit.
+	sizeBuckets = prometheus.ExponentialBuckets(64, 2, 15)
+)
+
+// Opts configures keyspace dumps.
+type Opts struct {
+	// Interval is the time between the start of two dumps.
+	Interval time.Duration
+	// Command is the LRU crawler dump command, CommandMetadump or
+	// CommandMgdump. Empty defaults to CommandMetadump.
+	Command string
+	// Slabs restricts dumps to these slab classes. Empty dumps all classes.
+	Slabs []int
+	// MaxItems stops a dump after this many items. Zero means no limit.
+	MaxItems int
+	// MaxDuration stops a dump after this long.
+	MaxDuration time.Duration
+	// ItemsPerSecond limits the rate items are read at, which in turn slows
+	// down the crawler on the server. Zero means no limit.
+	ItemsPerSecond float64
+	// SampleRate is the fraction of keys accounted. Keys are sampled by
+	// hash, so the same keys are accounted in every dump. Item counts and
+	// bytes are extrapolated to the whole keyspace. Zero accounts all keys.
+	SampleRate float64
+	// Rules map keys to labels. The first matching rule wins. Named capture
+	// groups become labels, a group named "prefix" or else the whole match
+	// sets the prefix label. Keys matching no rule are accounted as
+	// "_other". Without rules keys are split by PrefixDelimiter.
+	Rules []*regexp.Regexp
+	// PrefixDelimiter and PrefixDepth aggregate keys by their first
+	// PrefixDepth delimited segments. An empty delimiter keeps full keys.
+	PrefixDelimiter string
+	PrefixDepth     int
+	// MaxPrefixes bounds the number of distinct key prefixes exported. Keys
+	// with further prefixes are counted as "_other". Zero means no limit.
+	MaxPrefixes int
+}
+
+type usage struct {
+	labels []string
+	items  float64
+	bytes  float64
+}
+
+// snapshot is the result of a single dump.
+type snapshot struct {
+	timestamp time.Time
+	duration  time.Duration
+	items     int
+	complete  bool
+	// factor extrapolates the accounted items to the whole keyspace.
+	factor   float64
+	ttl      *histogram
+	size     *histogram
+	idle     *histogram
+	prefixes map[string]*usage
+}
+
+// Keyspace periodically dumps the metadata of the items stored in a
+// memcached server with the LRU crawler and exports the result of the last
+// dump. It implements prometheus.Collector.
+type Keyspace struct {
+	address   string
+	timeout   time.Duration
+	logger    *slog.Logger
+	tlsConfig *tls.Config
+	opts      Opts
+
+	labels []string
+
+	mu   sync.Mutex
+	last *snapshot
+
+	failures prometheus.Counter
+
+	items        *prometheus.Desc
+	bytes        *prometheus.Desc
+	ttl          *prometheus.Desc
+	size         *prometheus.Desc
+	idle         *prometheus.Desc
+	dumpItems    *prometheus.Desc
+	dumpDuration *prometheus.Desc
+	dumpComplete *prometheus.Desc
+	factor       *prometheus.Desc
+	lastDump     *prometheus.Desc
+}
+
+// New returns an initialized keyspace collector. Start must be called to
+// schedule the dumps. The optional constLabels are added to every metric.
+func New(address string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config, opts Opts, constLabels prometheus.Labels) *Keyspace {
+	if opts.Command == "" {
+		opts.Command = CommandMetadump
+	}
+	labels := ruleLabels(opts.Rules)
+	return &Keyspace{
+		address:   address,
+		timeout:   timeout,
+		logger:    logger,
+		tlsConfig: tlsConfig,
+		opts:      opts,
+		labels:    labels,
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystem,
+			Name:        "dump_failures_total",
+			Help:        "Total number of keyspace dumps which failed.",
+			ConstLabels: constLabels,
+		}),
+		items: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "items"),
+			"Estimated number of items per key prefix, extrapolated from the last keyspace dump.",
+			labels,
+			constLabels,
+		),
+		bytes: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "bytes"),
+			"Estimated size in bytes of the items per key prefix, extrapolated from the last keyspace dump.",
+			labels,
+			constLabels,
+		),
+		ttl: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "item_ttl_seconds"),
+			"Remaining time to live of the items seen in the last keyspace dump. Items without an expiry are not observed.",
+			nil, constLabels,
+		),
+		size: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "item_size_bytes"),
+			"Size of the items seen in the last keyspace dump.",
+			nil, constLabels,
+		),
+		idle: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "item_idle_seconds"),
+			"Time since the items seen in the last keyspace dump were last accessed.",
+			nil, constLabels,
+		),
+		dumpItems: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "dump_items"),
+			"Number of items processed by the last keyspace dump.",
+			nil, constLabels,
+		),
+		dumpDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "dump_duration_seconds"),
+			"Duration of the last keyspace dump.",
+			nil, constLabels,
+		),
+		dumpComplete: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "dump_complete"),
+			"Whether the last keyspace dump covered all items rather than being cut short by the item or duration limit.",
+			nil, constLabels,
+		),
+		factor: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "extrapolation_factor"),
+			"Factor the accounted items of the last keyspace dump were multiplied by to estimate the whole keyspace.",
+			nil, constLabels,
+		),
+		lastDump: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "last_dump_timestamp_seconds"),
+			"Unix timestamp of the last successful keyspace dump.",
+			nil, constLabels,
+		),
+	}
+}
+
+// ruleLabels returns the label names set by rules, starting with the prefix
+// label.
+func ruleLabels(rules []*regexp.Regexp) []string {
+	labels := []string{labelPrefix}
+	for _, r := range rules {
+		for _, name := range r.SubexpNames() {
+			if name != "" && !slices.Contains(labels, name) {
+				labels = append(labels, name)
+			}
+		}
+	}
+	return labels
+}
+
+// Start dumps the keyspace every interval until ctx is cancelled.
+func (k *Keyspace) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(k.opts.Interval)
+		defer ticker.Stop()
+		for {
+			k.update(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (k *Keyspace) update(ctx context.Context) {
+	s, err := k.dump(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			k.logger.Error("Failed to dump keyspace", "err", err)
+			k.failures.Inc()
+		}
+		return
+	}
+	k.mu.Lock()
+	k.last = s
+	k.mu.Unlock()
+}
+
+func (k *Keyspace) command() string {
+	if len(k.opts.Slabs) == 0 {
+		return "lru_crawler " + k.opts.Command + " all"
+	}
+	ids := make([]string, len(k.opts.Slabs))
+	for i, id := range k.opts.Slabs {
+		ids[i] = strconv.Itoa(id)
+	}
+	return "lru_crawler " + k.opts.Command + " " + strings.Join(ids, ",")
+}
+
+// sampled reports whether key is accounted.
+func (k *Keyspace) sampled(key string) bool {
+	if k.opts.SampleRate <= 0 || k.opts.SampleRate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return float64(h.Sum64()) < k.opts.SampleRate*math.MaxUint64
+}
+
+// totalItems returns the number of items in the dumped slab classes.
+func (k *Keyspace) totalItems(conn *client.Conn) (float64, error) {
+	stats, err := conn.Stats("items")
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for name, v := range stats {
+		f := strings.Split(name, ":")
+		if len(f) != 3 || f[0] != "items" || f[2] != "number" {
+			continue
+		}
+		if id, err := strconv.Atoi(f[1]); err != nil || len(k.opts.Slabs) > 0 && !slices.Contains(k.opts.Slabs, id) {
+			continue
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (k *Keyspace) dump(ctx context.Context) (*snapshot, error) {
+	conn, err := client.Dial(k.address, k.timeout, k.tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	// A dump can only be aborted by closing the connection.
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// Expiry and access times are absolute, so they are compared to the
+	// clock of the server.
+	stats, err := conn.Stats()
+	if err != nil {
+		return nil, err
+	}
+	now, err := strconv.ParseInt(stats["time"], 10, 64)
+	if err != nil {
+		return nil, errors.New("memcached did not report its time")
+	}
+	total, err := k.totalItems(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	// mgdump only returns keys, their metadata is fetched on a second
+	// connection while the dump is streamed.
+	var lookup *client.Conn
+	if k.opts.Command == CommandMgdump {
+		lookup, err = client.Dial(k.address, k.timeout, k.tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		defer lookup.Close()
+	}
+
+	start := time.Now()
+	if err := conn.Send(k.command(), nil); err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(start.Add(k.opts.MaxDuration)); err != nil {
+		return nil, err
+	}
+
+	s := &snapshot{
+		timestamp: start,
+		ttl:       newHistogram(ageBuckets),
+		size:      newHistogram(sizeBuckets),
+		idle:      newHistogram(ageBuckets),
+		prefixes:  map[string]*usage{},
+	}
+	for {
+		if k.opts.MaxItems > 0 && s.items >= k.opts.MaxItems {
+			break
+		}
+		line, err := conn.ReadLine()
+		if err != nil {
+			var netErr net.Error
+			if s.items > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		if line == "END" || line == "EN" {
+			s.complete = true
+			break
+		}
+		s.items++
+		if k.opts.ItemsPerSecond > 0 && s.items%pacingBatch == 0 {
+			due := start.Add(time.Duration(float64(s.items) / k.opts.ItemsPerSecond * float64(time.Second)))
+			if d := time.Until(due); d > 0 {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(d):
+				}
+			}
+		}
+
+		var it item
+		if lookup != nil {
+			dk, err := parseDumpedKey(line)
+			if err != nil {
+				return nil, err
+			}
+			if !k.sampled(dk.key) {
+				continue
+			}
+			var found bool
+			it, found, err = dk.fetch(lookup, now)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+		} else {
+			it, err = parseItem(line)
+			if err != nil {
+				return nil, err
+			}
+			if !k.sampled(it.key) {
+				continue
+			}
+		}
+		k.add(s, it, now)
+	}
+	s.duration = time.Since(start)
+
+	s.factor = 1
+	if k.opts.SampleRate > 0 && k.opts.SampleRate < 1 {
+		s.factor /= k.opts.SampleRate
+	}
+	if !s.complete && s.items > 0 && total > float64(s.items) {
+		s.factor *= total / float64(s.items)
+	}
+	return s, nil
+}
+
+func (k *Keyspace) add(s *snapshot, it item, now int64) {
+	if it.exp >= 0 {
+		s.ttl.observe(float64(max(it.exp-now, 0)))
+	}
+	s.size.observe(float64(it.size))
+	s.idle.observe(float64(max(now-it.la, 0)))
+
+	labels := k.labelValues(it.key)
+	id := strings.Join(labels, "\xff")
+	u, ok := s.prefixes[id]
+	if !ok {
+		if k.opts.MaxPrefixes > 0 && len(s.prefixes) >= k.opts.MaxPrefixes {
+			labels = k.otherLabels()
+			id = strings.Join(labels, "\xff")
+			u = s.prefixes[id]
+		}
+		if u == nil {
+			u = &usage{labels: labels}
+			s.prefixes[id] = u
+		}
+	}
+	u.items++
+	u.bytes += float64(it.size)
+}
+
+func (k *Keyspace) otherLabels() []string {
+	labels := make([]string, len(k.labels))
+	labels[0] = otherPrefix
+	return labels
+}
+
+// labelValues maps a key to the values of k.labels.
+func (k *Keyspace) labelValues(key string) []string {
+	if len(k.opts.Rules) == 0 {
+		return []string{k.prefix(key)}
+	}
+	for _, r := range k.opts.Rules {
+		m := r.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		labels := make([]string, len(k.labels))
+		labels[0] = m[0]
+		for i, name := range r.SubexpNames() {
+			if name != "" {
+				labels[slices.Index(k.labels, name)] = m[i]
+			}
+		}
+		return labels
+	}
+	return k.otherLabels()
+}
+
+func (k *Keyspace) prefix(key string) string {
+	if k.opts.PrefixDelimiter == "" || k.opts.PrefixDepth <= 0 {
+		return key
+	}
+	parts := strings.SplitN(key, k.opts.PrefixDelimiter, k.opts.PrefixDepth+1)
+	if len(parts) > k.opts.PrefixDepth {
+		return strings.Join(parts[:k.opts.PrefixDepth], k.opts.PrefixDelimiter)
+	}
+	return key
+}
+
+// Describe describes all the metrics exported by the keyspace collector. It
+// implements prometheus.Collector.
+func (k *Keyspace) Describe(ch chan<- *prometheus.Desc) {
+	ch <- k.items
+	ch <- k.bytes
+	ch <- k.ttl
+	ch <- k.size
+	ch <- k.idle
+	ch <- k.dumpItems
+	ch <- k.dumpDuration
+	ch <- k.dumpComplete
+	ch <- k.factor
+	ch <- k.lastDump
+	k.failures.Describe(ch)
+}
+
+// Collect delivers the result of the last successful dump. It implements
+// prometheus.Collector.
+func (k *Keyspace) Collect(ch chan<- prometheus.Metric) {
+	k.failures.Collect(ch)
+
+	k.mu.Lock()
+	s := k.last
+	k.mu.Unlock()
+	if s == nil {
+		return
+	}
+
+	for _, u := range s.prefixes {
+		ch <- prometheus.MustNewConstMetric(k.items, prometheus.GaugeValue, u.items*s.factor, u.labels...)
+		ch <- prometheus.MustNewConstMetric(k.bytes, prometheus.GaugeValue, u.bytes*s.factor, u.labels...)
+	}
+	for desc, h := range map[*prometheus.Desc]*histogram{k.ttl: s.ttl, k.size: s.size, k.idle: s.idle} {
+		ch <- prometheus.MustNewConstHistogram(desc, h.count, h.sum, h.buckets())
+	}
+	complete := 0.
+	if s.complete {
+		complete = 1
+	}
+	ch <- prometheus.MustNewConstMetric(k.dumpItems, prometheus.GaugeValue, float64(s.items))
+	ch <- prometheus.MustNewConstMetric(k.dumpDuration, prometheus.GaugeValue, s.duration.Seconds())
+	ch <- prometheus.MustNewConstMetric(k.dumpComplete, prometheus.GaugeValue, complete)
+	ch <- prometheus.MustNewConstMetric(k.factor, prometheus.GaugeValue, s.factor)
+	ch <- prometheus.MustNewConstMetric(k.lastDump, prometheus.GaugeValue, float64(s.timestamp.UnixNano())/1e9)
+}
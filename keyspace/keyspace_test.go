@@ -0,0 +1,291 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyspace
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+var testItems = []string{
+	"key=user%3A1 exp=1700000030 la=1699999990 cas=1 fetch=yes cls=1 size=70",
+	"key=user%3A2 exp=-1 la=1699999000 cas=2 fetch=no cls=1 size=90",
+	"key=session%3A1 exp=1700003600 la=1700000000 cas=3 fetch=no cls=2 size=200",
+	"key=cart%3A1 exp=1700000100 la=1699999999 cas=4 fetch=no cls=3 size=1000",
+}
+
+// dumpServer answers stats with the server time and the item counts of
+// testItems, dump with lines and mg with the metadata of testItems.
+func dumpServer(t *testing.T, dump string, lines []string) *memcachedtest.Server {
+	stats := memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		return memcachedtest.Stats{
+			Stats: map[string]string{"time": "1700000000"},
+			Items: map[string]string{"items:1:number": "2", "items:2:number": "1", "items:3:number": "1"},
+		}
+	})
+	meta := map[string]string{
+		"mg user:1 s t l h u":         "HD s70 t30 l10 h1",
+		"mg user:2 s t l h u":         "HD s90 t-1 l1000 h0",
+		"mg c2Vzc2lvbjox s t l h u b": "HD s200 t3600 l0 h0",
+		"mg /zox s t l h u b":         "HD s10 t-1 l0 h0",
+	}
+	return memcachedtest.NewServer(t, func(w *bufio.Writer, r *bufio.Reader, line string) bool {
+		if strings.HasPrefix(line, "mg ") {
+			resp, ok := meta[line]
+			if !ok {
+				resp = "EN"
+			}
+			w.WriteString(resp + "\r\n")
+			return true
+		}
+		if line != dump {
+			return stats(w, r, line)
+		}
+		for _, l := range lines {
+			w.WriteString(l + "\r\n")
+		}
+		return true
+	})
+}
+
+func TestKeyspace(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		srv := dumpServer(t, "lru_crawler metadump all", append(testItems, "END"))
+		k := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil, Opts{
+			MaxDuration:     time.Second,
+			PrefixDelimiter: ":",
+			PrefixDepth:     1,
+			MaxPrefixes:     2,
+		}, nil)
+		k.update(context.Background())
+
+		want := `
+# HELP memcached_keyspace_bytes Estimated size in bytes of the items per key prefix, extrapolated from the last keyspace dump.
+# TYPE memcached_keyspace_bytes gauge
+memcached_keyspace_bytes{prefix="_other"} 1000
+memcached_keyspace_bytes{prefix="session"} 200
+memcached_keyspace_bytes{prefix="user"} 160
+# HELP memcached_keyspace_dump_complete Whether the last keyspace dump covered all items rather than being cut short by the item or duration limit.
+# TYPE memcached_keyspace_dump_complete gauge
+memcached_keyspace_dump_complete 1
+# HELP memcached_keyspace_dump_items Number of items processed by the last keyspace dump.
+# TYPE memcached_keyspace_dump_items gauge
+memcached_keyspace_dump_items 4
+# HELP memcached_keyspace_items Estimated number of items per key prefix, extrapolated from the last keyspace dump.
+# TYPE memcached_keyspace_items gauge
+memcached_keyspace_items{prefix="_other"} 1
+memcached_keyspace_items{prefix="session"} 1
+memcached_keyspace_items{prefix="user"} 2
+# HELP memcached_keyspace_item_ttl_seconds Remaining time to live of the items seen in the last keyspace dump. Items without an expiry are not observed.
+# TYPE memcached_keyspace_item_ttl_seconds histogram
+memcached_keyspace_item_ttl_seconds_bucket{le="1"} 0
+memcached_keyspace_item_ttl_seconds_bucket{le="4"} 0
+memcached_keyspace_item_ttl_seconds_bucket{le="16"} 0
+memcached_keyspace_item_ttl_seconds_bucket{le="64"} 1
+memcached_keyspace_item_ttl_seconds_bucket{le="256"} 2
+memcached_keyspace_item_ttl_seconds_bucket{le="1024"} 2
+memcached_keyspace_item_ttl_seconds_bucket{le="4096"} 3
+memcached_keyspace_item_ttl_seconds_bucket{le="16384"} 3
+memcached_keyspace_item_ttl_seconds_bucket{le="65536"} 3
+memcached_keyspace_item_ttl_seconds_bucket{le="262144"} 3
+memcached_keyspace_item_ttl_seconds_bucket{le="1.048576e+06"} 3
+memcached_keyspace_item_ttl_seconds_bucket{le="4.194304e+06"} 3
+memcached_keyspace_item_ttl_seconds_bucket{le="+Inf"} 3
+memcached_keyspace_item_ttl_seconds_sum 3730
+memcached_keyspace_item_ttl_seconds_count 3
+`
+		if err := testutil.CollectAndCompare(k, strings.NewReader(want),
+			"memcached_keyspace_bytes", "memcached_keyspace_dump_complete", "memcached_keyspace_dump_items",
+			"memcached_keyspace_items", "memcached_keyspace_item_ttl_seconds"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Invalid UTF-8", func(t *testing.T) {
+		t.Parallel()
+
+		srv := dumpServer(t, "lru_crawler metadump all", []string{
+			"key=%FFabc%3A1 exp=-1 la=1700000000 cas=1 fetch=no cls=1 size=70",
+			"END",
+		})
+		k := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil, Opts{
+			MaxDuration:     time.Second,
+			PrefixDelimiter: ":",
+			PrefixDepth:     1,
+		}, nil)
+		k.update(context.Background())
+
+		// Collecting panics on label values which are not valid UTF-8.
+		want := `
+# HELP memcached_keyspace_items Estimated number of items per key prefix, extrapolated from the last keyspace dump.
+# TYPE memcached_keyspace_items gauge
+memcached_keyspace_items{prefix="` + "\uFFFD" + `abc"} 1
+`
+		if err := testutil.CollectAndCompare(k, strings.NewReader(want), "memcached_keyspace_items"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Item limit", func(t *testing.T) {
+		t.Parallel()
+
+		srv := dumpServer(t, "lru_crawler metadump 1,2", append(testItems, "END"))
+		k := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil, Opts{
+			Slabs:       []int{1, 2},
+			MaxItems:    2,
+			MaxDuration: time.Second,
+		}, nil)
+		k.update(context.Background())
+
+		want := `
+# HELP memcached_keyspace_dump_complete Whether the last keyspace dump covered all items rather than being cut short by the item or duration limit.
+# TYPE memcached_keyspace_dump_complete gauge
+memcached_keyspace_dump_complete 0
+# HELP memcached_keyspace_dump_items Number of items processed by the last keyspace dump.
+# TYPE memcached_keyspace_dump_items gauge
+memcached_keyspace_dump_items 2
+`
+		if err := testutil.CollectAndCompare(k, strings.NewReader(want),
+			"memcached_keyspace_dump_complete", "memcached_keyspace_dump_items"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Duration limit", func(t *testing.T) {
+		t.Parallel()
+
+		// The dump never ends.
+		srv := dumpServer(t, "lru_crawler metadump all", testItems[:1])
+		k := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil, Opts{
+			MaxDuration: 100 * time.Millisecond,
+		}, nil)
+		k.update(context.Background())
+
+		if got := testutil.ToFloat64(k.failures); got != 0 {
+			t.Errorf("want no failures, got %v", got)
+		}
+		if n := testutil.CollectAndCount(k, "memcached_keyspace_dump_complete"); n != 1 {
+			t.Errorf("want a truncated dump to be exported, got %d series", n)
+		}
+	})
+
+	t.Run("Busy", func(t *testing.T) {
+		t.Parallel()
+
+		srv := dumpServer(t, "lru_crawler metadump all", []string{"BUSY currently processing crawler request"})
+		k := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil, Opts{
+			MaxDuration: time.Second,
+		}, nil)
+		k.update(context.Background())
+
+		if got := testutil.ToFloat64(k.failures); got != 1 {
+			t.Errorf("want 1 failure, got %v", got)
+		}
+		if n := testutil.CollectAndCount(k, "memcached_keyspace_dump_items"); n != 0 {
+			t.Errorf("want no dump metrics, got %d", n)
+		}
+	})
+
+	t.Run("Rules", func(t *testing.T) {
+		t.Parallel()
+
+		srv := dumpServer(t, "lru_crawler metadump all", append(testItems, "END"))
+		k := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil, Opts{
+			MaxDuration: time.Second,
+			Rules: []*regexp.Regexp{
+				regexp.MustCompile(`^(?P<prefix>user):(?P<id>[0-9])`),
+				regexp.MustCompile(`^session:`),
+			},
+		}, nil)
+		k.update(context.Background())
+
+		want := `
+# HELP memcached_keyspace_items Estimated number of items per key prefix, extrapolated from the last keyspace dump.
+# TYPE memcached_keyspace_items gauge
+memcached_keyspace_items{id="",prefix="_other"} 1
+memcached_keyspace_items{id="",prefix="session:"} 1
+memcached_keyspace_items{id="1",prefix="user"} 1
+memcached_keyspace_items{id="2",prefix="user"} 1
+`
+		if err := testutil.CollectAndCompare(k, strings.NewReader(want), "memcached_keyspace_items"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Extrapolation", func(t *testing.T) {
+		t.Parallel()
+
+		// Two of four items are dumped and half of the keys are sampled.
+		srv := dumpServer(t, "lru_crawler metadump all", append(testItems, "END"))
+		k := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil, Opts{
+			MaxItems:    2,
+			MaxDuration: time.Second,
+			SampleRate:  0.5,
+		}, nil)
+		k.update(context.Background())
+
+		want := `
+# HELP memcached_keyspace_extrapolation_factor Factor the accounted items of the last keyspace dump were multiplied by to estimate the whole keyspace.
+# TYPE memcached_keyspace_extrapolation_factor gauge
+memcached_keyspace_extrapolation_factor 4
+`
+		if err := testutil.CollectAndCompare(k, strings.NewReader(want), "memcached_keyspace_extrapolation_factor"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Mgdump", func(t *testing.T) {
+		t.Parallel()
+
+		srv := dumpServer(t, "lru_crawler mgdump all", []string{
+			"mg user:1", "mg user:2", "mg c2Vzc2lvbjox b", "mg /zox b", "mg gone", "EN",
+		})
+		k := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil, Opts{
+			Command:         CommandMgdump,
+			MaxDuration:     time.Second,
+			PrefixDelimiter: ":",
+			PrefixDepth:     1,
+		}, nil)
+		k.update(context.Background())
+
+		want := `
+# HELP memcached_keyspace_bytes Estimated size in bytes of the items per key prefix, extrapolated from the last keyspace dump.
+# TYPE memcached_keyspace_bytes gauge
+memcached_keyspace_bytes{prefix="session"} 200
+memcached_keyspace_bytes{prefix="user"} 160
+memcached_keyspace_bytes{prefix="` + "\uFFFD" + `"} 10
+# HELP memcached_keyspace_dump_items Number of items processed by the last keyspace dump.
+# TYPE memcached_keyspace_dump_items gauge
+memcached_keyspace_dump_items 5
+`
+		if err := testutil.CollectAndCompare(k, strings.NewReader(want),
+			"memcached_keyspace_bytes", "memcached_keyspace_dump_items"); err != nil {
+			t.Error(err)
+		}
+		if got := testutil.ToFloat64(k.failures); got != 0 {
+			t.Errorf("want no failures, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otlp periodically pushes the metrics of the exporter to an
+// OpenTelemetry collector over OTLP.
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promBridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/prometheus/memcached_exporter/client"
+)
+
+// Protocols used to push metrics.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// Opts configures the OTLP push mode.
+type Opts struct {
+	// Endpoint is the URL of the collector, e.g. http://localhost:4317 for
+	// gRPC or http://localhost:4318/v1/metrics for HTTP. Plain http disables
+	// TLS.
+	Endpoint string
+	// Protocol is ProtocolGRPC or ProtocolHTTP.
+	Protocol string
+	// Interval between two pushes.
+	Interval time.Duration
+	// Headers are sent with every push, e.g. for authentication.
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute.
+	ServiceName string
+}
+
+// Pusher gathers the metrics registered for a memcached server and pushes
+// them as OTLP metrics. Counters become monotonic cumulative sums, gauges
+// stay gauges.
+type Pusher struct {
+	address   func() string
+	timeout   time.Duration
+	logger    *slog.Logger
+	tlsConfig *tls.Config
+	gatherer  prometheus.Gatherer
+	opts      Opts
+
+	// version is the last version reported by the server at
+	// versionAddress, empty until it could be queried. They are only
+	// accessed by the push loop.
+	version        string
+	versionAddress string
+}
+
+// New returns a pusher for the metrics of gatherer, which describe the
+// memcached server at the address returned by address. The address may
+// change between pushes, e.g. when the configuration is reloaded.
+func New(address func() string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config, gatherer prometheus.Gatherer, opts Opts) *Pusher {
+	return &Pusher{
+		address:   address,
+		timeout:   timeout,
+		logger:    logger,
+		tlsConfig: tlsConfig,
+		gatherer:  gatherer,
+		opts:      opts,
+	}
+}
+
+// Start pushes metrics every interval until ctx is cancelled, when the
+// metrics are pushed one last time. Failed pushes are logged.
+func (p *Pusher) Start(ctx context.Context) error {
+	exp, err := p.newExporter(ctx)
+	if err != nil {
+		return err
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		p.logger.Error("Failed to gather OTLP metrics", "err", err)
+	}))
+	producer := promBridge.NewMetricProducer(promBridge.WithGatherer(p.gatherer))
+
+	go func() {
+		ticker := time.NewTicker(p.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.push(ctx, exp, producer)
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), p.opts.Interval)
+				p.push(shutdownCtx, exp, producer)
+				if err := exp.Shutdown(shutdownCtx); err != nil {
+					p.logger.Error("Failed to shut down OTLP push", "err", err)
+				}
+				cancel()
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// push gathers and pushes the metrics once. The resource is built for every
+// push, so it follows upgrades of the server and picks up the version once
+// the server becomes reachable.
+func (p *Pusher) push(ctx context.Context, exp sdkmetric.Exporter, producer sdkmetric.Producer) {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.Interval)
+	defer cancel()
+
+	scopes, err := producer.Produce(ctx)
+	if err != nil {
+		p.logger.Error("Failed to gather OTLP metrics", "err", err)
+		return
+	}
+	rm := &metricdata.ResourceMetrics{
+		Resource:     resource.NewSchemaless(p.attributes()...),
+		ScopeMetrics: scopes,
+	}
+	if err := exp.Export(ctx, rm); err != nil {
+		p.logger.Error("Failed to push OTLP metrics", "endpoint", p.opts.Endpoint, "err", err)
+	}
+}
+
+func (p *Pusher) newExporter(ctx context.Context) (sdkmetric.Exporter, error) {
+	switch p.opts.Protocol {
+	case ProtocolGRPC:
+		return otlpmetricgrpc.New(ctx,
+			otlpmetricgrpc.WithEndpointURL(p.opts.Endpoint),
+			otlpmetricgrpc.WithHeaders(p.opts.Headers),
+		)
+	case ProtocolHTTP:
+		return otlpmetrichttp.New(ctx,
+			otlpmetrichttp.WithEndpointURL(p.opts.Endpoint),
+			otlpmetrichttp.WithHeaders(p.opts.Headers),
+		)
+	}
+	return nil, fmt.Errorf("unknown OTLP protocol %q", p.opts.Protocol)
+}
+
+// attributes returns the resource attributes describing the memcached
+// server. If the version can't be queried the last known one is used, if
+// any.
+func (p *Pusher) attributes() []attribute.KeyValue {
+	address := p.address()
+	attrs := []attribute.KeyValue{attribute.String("service.name", p.opts.ServiceName)}
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		attrs = append(attrs, attribute.String("server.address", host))
+		if n, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, attribute.Int("server.port", n))
+		}
+	} else {
+		attrs = append(attrs, attribute.String("server.address", address))
+	}
+
+	if address != p.versionAddress {
+		p.version, p.versionAddress = "", address
+	}
+	if v, err := p.queryVersion(address); err != nil {
+		p.logger.Warn("Failed to query memcached version for OTLP resource", "err", err)
+	} else {
+		p.version = v
+	}
+	if p.version == "" {
+		return attrs
+	}
+	return append(attrs, attribute.String("service.version", p.version))
+}
+
+func (p *Pusher) queryVersion(address string) (string, error) {
+	conn, err := client.Dial(address, p.timeout, p.tlsConfig)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.Version()
+}
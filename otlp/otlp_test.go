@@ -0,0 +1,224 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/promslog"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+type receiver struct {
+	colmetricpb.UnimplementedMetricsServiceServer
+	requests chan *colmetricpb.ExportMetricsServiceRequest
+}
+
+func (r *receiver) Export(_ context.Context, req *colmetricpb.ExportMetricsServiceRequest) (*colmetricpb.ExportMetricsServiceResponse, error) {
+	select {
+	case r.requests <- req:
+	default:
+	}
+	return &colmetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var m colmetricpb.ExportMetricsServiceRequest
+	if err := proto.Unmarshal(body, &m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Export(req.Context(), &m)
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	b, _ := proto.Marshal(&colmetricpb.ExportMetricsServiceResponse{})
+	w.Write(b)
+}
+
+func memcachedServer(t *testing.T, version *atomic.Value) *memcachedtest.Server {
+	stats := memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		return memcachedtest.Stats{
+			Stats: map[string]string{
+				"version":       "1.6.21",
+				"uptime":        "100",
+				"time":          "1700000000",
+				"cmd_set":       "3",
+				"cas_hits":      "0",
+				"cas_misses":    "0",
+				"cas_badval":    "0",
+				"get_hits":      "5",
+				"rusage_user":   "0.5",
+				"rusage_system": "0.25",
+			},
+			Settings: map[string]string{"maxconns": "1024"},
+		}
+	})
+	return memcachedtest.NewServer(t, func(w *bufio.Writer, r *bufio.Reader, line string) bool {
+		if line == "version" {
+			w.WriteString("VERSION " + version.Load().(string) + "\r\n")
+			return true
+		}
+		return stats(w, r, line)
+	})
+}
+
+func newVersion(v string) *atomic.Value {
+	var version atomic.Value
+	version.Store(v)
+	return &version
+}
+
+// resourceAttributes returns the string attributes of the single resource of
+// req.
+func resourceAttributes(t *testing.T, req *colmetricpb.ExportMetricsServiceRequest) map[string]string {
+	t.Helper()
+	rm := req.GetResourceMetrics()
+	if len(rm) != 1 {
+		t.Fatalf("want 1 resource, got %d", len(rm))
+	}
+	attrs := map[string]string{}
+	for _, kv := range rm[0].GetResource().GetAttributes() {
+		attrs[kv.GetKey()] = kv.GetValue().GetStringValue()
+	}
+	return attrs
+}
+
+func TestPusher(t *testing.T) {
+	srv := memcachedServer(t, newVersion("1.6.21"))
+
+	for _, tt := range []struct {
+		name     string
+		protocol string
+		listen   func(t *testing.T, r *receiver) string
+	}{
+		{"HTTP", ProtocolHTTP, func(t *testing.T, r *receiver) string {
+			s := httptest.NewServer(r)
+			t.Cleanup(s.Close)
+			return s.URL + "/v1/metrics"
+		}},
+		{"gRPC", ProtocolGRPC, func(t *testing.T, r *receiver) string {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := grpc.NewServer()
+			colmetricpb.RegisterMetricsServiceServer(s, r)
+			go s.Serve(l)
+			t.Cleanup(s.Stop)
+			return "http://" + l.Addr().String()
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &receiver{requests: make(chan *colmetricpb.ExportMetricsServiceRequest, 1)}
+			endpoint := tt.listen(t, r)
+
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(exporter.New(srv.Addr, time.Second, promslog.NewNopLogger(), nil))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			p := New(func() string { return srv.Addr }, time.Second, promslog.NewNopLogger(), nil, registry, Opts{
+				Endpoint:    endpoint,
+				Protocol:    tt.protocol,
+				Interval:    50 * time.Millisecond,
+				ServiceName: "memcached",
+			})
+			if err := p.Start(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			var req *colmetricpb.ExportMetricsServiceRequest
+			select {
+			case req = <-r.requests:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for pushed metrics")
+			}
+
+			attrs := resourceAttributes(t, req)
+			if attrs["service.name"] != "memcached" || attrs["server.address"] != "127.0.0.1" || attrs["service.version"] != "1.6.21" {
+				t.Errorf("unexpected resource attributes %v", attrs)
+			}
+
+			metrics := map[string]*metricpb.Metric{}
+			for _, sm := range req.GetResourceMetrics()[0].GetScopeMetrics() {
+				for _, m := range sm.GetMetrics() {
+					metrics[m.GetName()] = m
+				}
+			}
+			if up := metrics["memcached_up"].GetGauge().GetDataPoints(); len(up) != 1 || up[0].GetAsDouble() != 1 {
+				t.Errorf("unexpected memcached_up %v", up)
+			}
+			sum := metrics["memcached_commands_total"].GetSum()
+			if sum == nil || !sum.GetIsMonotonic() || sum.GetAggregationTemporality() != metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+				t.Errorf("want memcached_commands_total as a cumulative monotonic sum, got %v", metrics["memcached_commands_total"])
+			}
+		})
+	}
+}
+
+func TestPusherVersion(t *testing.T) {
+	version := newVersion("1.6.21")
+	srv := memcachedServer(t, version)
+	r := &receiver{requests: make(chan *colmetricpb.ExportMetricsServiceRequest, 1)}
+	s := httptest.NewServer(r)
+	t.Cleanup(s.Close)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter.New(srv.Addr, time.Second, promslog.NewNopLogger(), nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := New(func() string { return srv.Addr }, time.Second, promslog.NewNopLogger(), nil, registry, Opts{
+		Endpoint:    s.URL + "/v1/metrics",
+		Protocol:    ProtocolHTTP,
+		Interval:    50 * time.Millisecond,
+		ServiceName: "memcached",
+	})
+	if err := p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// An upgraded server is reflected by the following pushes.
+	for _, want := range []string{"1.6.21", "1.6.22"} {
+		version.Store(want)
+		deadline := time.After(5 * time.Second)
+		for got := ""; got != want; {
+			select {
+			case req := <-r.requests:
+				got = resourceAttributes(t, req)["service.version"]
+			case <-deadline:
+				t.Fatalf("timed out waiting for service.version %s", want)
+			}
+		}
+	}
+}
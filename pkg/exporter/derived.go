@@ -0,0 +1,128 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/grobie/gomemcache/memcache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// derived computes ratios and efficiency metrics from the stats which are
+// easy to get wrong in PromQL.
+type derived struct {
+	hitRatio              *prometheus.Desc
+	memoryUtilization     *prometheus.Desc
+	connectionUtilization *prometheus.Desc
+	slabChunkUtilization  *prometheus.Desc
+	slabWastedBytes       *prometheus.Desc
+}
+
+func newDerived(constLabels prometheus.Labels) *derived {
+	return &derived{
+		hitRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "hit_ratio"),
+			"Ratio of hits to all lookups per command since the server started. Bad CAS values count as misses.",
+			[]string{"command"},
+			constLabels,
+		),
+		memoryUtilization: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "memory_utilization_ratio"),
+			"Ratio of bytes used to store items to the configured memory limit.",
+			nil,
+			constLabels,
+		),
+		connectionUtilization: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "connection_utilization_ratio"),
+			"Ratio of open connections to the configured maximum.",
+			nil,
+			constLabels,
+		),
+		slabChunkUtilization: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "chunk_utilization_ratio"),
+			"Ratio of used chunks to all chunks allocated to the slab class.",
+			[]string{"slab"},
+			constLabels,
+		),
+		slabWastedBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "wasted_bytes"),
+			"Bytes allocated to the slab class but not requested by items, including free chunks.",
+			[]string{"slab"},
+			constLabels,
+		),
+	}
+}
+
+// EnableDerivedMetrics adds hit ratios as well as memory, connection and slab
+// utilisation metrics computed from the same stats as the raw metrics.
+func (e *Exporter) EnableDerivedMetrics() {
+	e.derived = newDerived(e.constLabels)
+}
+
+func (d *derived) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.hitRatio
+	ch <- d.memoryUtilization
+	ch <- d.connectionUtilization
+	ch <- d.slabChunkUtilization
+	ch <- d.slabWastedBytes
+}
+
+// ratio sends numerator/denominator unless any value is missing or the
+// denominator is zero.
+func ratio(ch chan<- prometheus.Metric, desc *prometheus.Desc, numerator, denominator float64, err error, labelValues ...string) {
+	if err != nil || denominator == 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, numerator/denominator, labelValues...)
+}
+
+// collect derives the metrics. Values which cannot be parsed have already
+// been reported by parseStats, so they are skipped here.
+func (d *derived) collect(ch chan<- prometheus.Metric, stats map[net.Addr]memcache.Stats, statsSettings map[net.Addr]map[string]string) {
+	for addr, t := range stats {
+		s := t.Stats
+		for _, op := range []string{"get", "delete", "incr", "decr", "touch"} {
+			hits, err := sum(s, op+"_hits")
+			lookups, lookupsErr := sum(s, op+"_hits", op+"_misses")
+			ratio(ch, d.hitRatio, hits, lookups, firstError(err, lookupsErr), op)
+		}
+		casHits, err := sum(s, "cas_hits")
+		casLookups, casErr := sum(s, "cas_hits", "cas_misses", "cas_badval")
+		ratio(ch, d.hitRatio, casHits, casLookups, firstError(err, casErr), "cas")
+
+		bytes, err := sum(s, "bytes")
+		limit, limitErr := sum(s, "limit_maxbytes")
+		ratio(ch, d.memoryUtilization, bytes, limit, firstError(err, limitErr))
+
+		conns, err := sum(s, "curr_connections")
+		maxConns, maxErr := sum(statsSettings[addr], "maxconns")
+		ratio(ch, d.connectionUtilization, conns, maxConns, firstError(err, maxErr))
+
+		for slab, v := range t.Slabs {
+			slab := strconv.Itoa(slab)
+
+			used, err := sum(v, "used_chunks")
+			total, totalErr := sum(v, "total_chunks")
+			ratio(ch, d.slabChunkUtilization, used, total, firstError(err, totalErr), slab)
+
+			size, sizeErr := sum(v, "chunk_size")
+			requested, requestedErr := sum(v, "mem_requested")
+			if firstError(totalErr, sizeErr, requestedErr) == nil {
+				ch <- prometheus.MustNewConstMetric(d.slabWastedBytes, prometheus.GaugeValue, total*size-requested, slab)
+			}
+		}
+	}
+}
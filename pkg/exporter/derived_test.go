@@ -0,0 +1,98 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestDerivedMetrics(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		s := testStats()
+		for k, v := range map[string]string{
+			"get_hits":         "75",
+			"get_misses":       "25",
+			"delete_hits":      "0",
+			"delete_misses":    "0",
+			"cas_hits":         "1",
+			"cas_misses":       "1",
+			"cas_badval":       "2",
+			"bytes":            "256",
+			"limit_maxbytes":   "1024",
+			"curr_connections": "10",
+		} {
+			s.Stats[k] = v
+		}
+		s.Slabs = map[string]string{
+			"1:chunk_size":    "96",
+			"1:total_chunks":  "10",
+			"1:used_chunks":   "4",
+			"1:free_chunks":   "6",
+			"1:total_pages":   "1",
+			"1:mem_requested": "300",
+			"2:chunk_size":    "120",
+			"2:total_chunks":  "10",
+			"2:used_chunks":   "10",
+			"2:free_chunks":   "0",
+			"2:total_pages":   "2",
+			"2:mem_requested": "1200",
+		}
+		s.Items = map[string]string{
+			"items:1:evicted": "10",
+			"items:2:evicted": "40",
+		}
+		return s
+	}))
+
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+	e.EnableDerivedMetrics()
+
+	// Commands without lookups have no ratio.
+	want := `
+# HELP memcached_connection_utilization_ratio Ratio of open connections to the configured maximum.
+# TYPE memcached_connection_utilization_ratio gauge
+memcached_connection_utilization_ratio 0.009765625
+# HELP memcached_hit_ratio Ratio of hits to all lookups per command since the server started. Bad CAS values count as misses.
+# TYPE memcached_hit_ratio gauge
+memcached_hit_ratio{command="cas"} 0.25
+memcached_hit_ratio{command="get"} 0.75
+# HELP memcached_memory_utilization_ratio Ratio of bytes used to store items to the configured memory limit.
+# TYPE memcached_memory_utilization_ratio gauge
+memcached_memory_utilization_ratio 0.25
+# HELP memcached_slab_chunk_utilization_ratio Ratio of used chunks to all chunks allocated to the slab class.
+# TYPE memcached_slab_chunk_utilization_ratio gauge
+memcached_slab_chunk_utilization_ratio{slab="1"} 0.4
+memcached_slab_chunk_utilization_ratio{slab="2"} 1
+# HELP memcached_slab_wasted_bytes Bytes allocated to the slab class but not requested by items, including free chunks.
+# TYPE memcached_slab_wasted_bytes gauge
+memcached_slab_wasted_bytes{slab="1"} 660
+memcached_slab_wasted_bytes{slab="2"} 0
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want),
+		"memcached_connection_utilization_ratio", "memcached_hit_ratio", "memcached_memory_utilization_ratio",
+		"memcached_slab_chunk_utilization_ratio", "memcached_slab_wasted_bytes"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := testutil.CollectAndCount(New(srv.Addr, time.Second, promslog.NewNopLogger(), nil), "memcached_hit_ratio"); n != 0 {
+		t.Errorf("want no derived metrics unless enabled, got %d", n)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/grobie/gomemcache/memcache"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.yaml.in/yaml/v2"
+)
+
+// defaultPool is the baseline of targets which are not part of any pool.
+const defaultPool = "default"
+
+// Baseline holds the expected settings and stats of a pool of memcached
+// servers.
+type Baseline struct {
+	// Targets are the addresses the baseline applies to.
+	Targets []string `yaml:"targets"`
+	// Settings are compared against stats settings.
+	Settings map[string]string `yaml:"settings"`
+	// Stats are compared against stats, e.g. limit_maxbytes or version.
+	Stats map[string]string `yaml:"stats"`
+}
+
+// Baselines maps pool names to their baseline.
+type Baselines map[string]*Baseline
+
+type baselinesFile struct {
+	Pools Baselines `yaml:"pools"`
+}
+
+// LoadBaselines reads baselines from a YAML file of the form
+//
+//	pools:
+//	  sessions:
+//	    targets: [memcached-1:11211, memcached-2:11211]
+//	    settings:
+//	      maxconns: 4096
+//	    stats:
+//	      version: 1.6.21
+func LoadBaselines(filename string) (Baselines, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var f baselinesFile
+	if err := yaml.UnmarshalStrict(content, &f); err != nil {
+		return nil, fmt.Errorf("parsing baselines %s: %w", filename, err)
+	}
+	// Both maps are reported by the setting label, so a key in both would
+	// yield duplicate series.
+	for name, b := range f.Pools {
+		for k := range b.Settings {
+			if _, ok := b.Stats[k]; ok {
+				return nil, fmt.Errorf("baseline of pool %q sets %q in both settings and stats", name, k)
+			}
+		}
+	}
+	return f.Pools, nil
+}
+
+// For returns the baseline of pool or, if pool is empty, of the first pool
+// listing target and else the default pool. It returns nil if there is none.
+func (b Baselines) For(pool, target string) *Baseline {
+	if pool != "" {
+		return b[pool]
+	}
+	for _, name := range slices.Sorted(maps.Keys(b)) {
+		if slices.Contains(b[name].Targets, target) {
+			return b[name]
+		}
+	}
+	return b[defaultPool]
+}
+
+type drift struct {
+	baseline *Baseline
+
+	drift   *prometheus.Desc
+	drifted *prometheus.Desc
+}
+
+// SetBaseline compares stats settings and stats against baseline on every
+// scrape.
+func (e *Exporter) SetBaseline(baseline *Baseline) {
+	e.drift = &drift{
+		baseline: baseline,
+		drift: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "config", "drift"),
+			"Settings or stats whose value differs from the configured baseline.",
+			[]string{"setting", "expected", "actual"},
+			e.constLabels,
+		),
+		drifted: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "config", "drifted_settings"),
+			"Number of settings or stats whose value differs from the configured baseline.",
+			nil,
+			e.constLabels,
+		),
+	}
+}
+
+func (d *drift) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.drift
+	ch <- d.drifted
+}
+
+func (d *drift) collect(ch chan<- prometheus.Metric, stats map[net.Addr]memcache.Stats, statsSettings map[net.Addr]map[string]string) {
+	for addr, t := range stats {
+		settings, ok := statsSettings[addr]
+		if !ok {
+			continue
+		}
+		drifted := d.compare(ch, d.baseline.Settings, settings) + d.compare(ch, d.baseline.Stats, t.Stats)
+		ch <- prometheus.MustNewConstMetric(d.drifted, prometheus.GaugeValue, float64(drifted))
+	}
+}
+
+// compare reports every expected value which differs from actual and returns
+// their number.
+func (d *drift) compare(ch chan<- prometheus.Metric, expected, actual map[string]string) int {
+	var n int
+	for k, want := range expected {
+		got := actual[k]
+		if equalValues(want, got) {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(d.drift, prometheus.GaugeValue, 1, k, want, got)
+		n++
+	}
+	return n
+}
+
+// equalValues compares numbers by value, so 1.25 matches 1.250, and
+// everything else literally.
+func equalValues(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && x == y
+}
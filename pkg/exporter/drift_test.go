@@ -0,0 +1,121 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestLoadBaselines(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		filename := filepath.Join(t.TempDir(), "baselines.yml")
+		content := `
+pools:
+  default:
+    settings:
+      maxconns: 1024
+  sessions:
+    targets: [memcached-1:11211]
+    stats:
+      version: 1.6.21
+`
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		b, err := LoadBaselines(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := b.For("", "memcached-1:11211"); got != b["sessions"] {
+			t.Errorf("want sessions baseline for a listed target, got %+v", got)
+		}
+		if got := b.For("", "memcached-2:11211"); got != b["default"] || got.Settings["maxconns"] != "1024" {
+			t.Errorf("want default baseline for other targets, got %+v", got)
+		}
+		if got := b.For("unknown", "memcached-1:11211"); got != nil {
+			t.Errorf("want no baseline for an unknown pool, got %+v", got)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		t.Parallel()
+
+		filename := filepath.Join(t.TempDir(), "baselines.yml")
+		if err := os.WriteFile(filename, []byte("pools:\n  default:\n    setting: {}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadBaselines(filename); err == nil {
+			t.Error("expect return error but not")
+		}
+	})
+
+	t.Run("Overlapping keys", func(t *testing.T) {
+		t.Parallel()
+
+		filename := filepath.Join(t.TempDir(), "baselines.yml")
+		content := "pools:\n  default:\n    settings:\n      maxconns: 1024\n    stats:\n      maxconns: 1024\n"
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadBaselines(filename); err == nil {
+			t.Error("key in both settings and stats was accepted")
+		}
+	})
+}
+
+func TestDrift(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		s := testStats()
+		s.Settings["growth_factor"] = "1.25"
+		s.Settings["num_threads"] = "4"
+		return s
+	}))
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+	e.SetBaseline(&Baseline{
+		Settings: map[string]string{
+			"maxconns":      "4096",
+			"growth_factor": "1.250",
+			"num_threads":   "4",
+			"item_size_max": "1048576",
+		},
+		Stats: map[string]string{
+			"version": "1.6.22",
+		},
+	})
+
+	want := `
+# HELP memcached_config_drift Settings or stats whose value differs from the configured baseline.
+# TYPE memcached_config_drift gauge
+memcached_config_drift{actual="",expected="1048576",setting="item_size_max"} 1
+memcached_config_drift{actual="1.6.21",expected="1.6.22",setting="version"} 1
+memcached_config_drift{actual="1024",expected="4096",setting="maxconns"} 1
+# HELP memcached_config_drifted_settings Number of settings or stats whose value differs from the configured baseline.
+# TYPE memcached_config_drifted_settings gauge
+memcached_config_drifted_settings 3
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), "memcached_config_drift", "memcached_config_drifted_settings"); err != nil {
+		t.Fatal(err)
+	}
+}
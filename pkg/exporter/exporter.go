@@ -17,17 +17,22 @@ import (
 	"crypto/tls"
 	"errors"
 	"log/slog"
+	"math"
 	"net"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/grobie/gomemcache/memcache"
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/client"
 )
 
 const (
 	Namespace           = "memcached"
+	exporterNamespace   = "memcached_exporter"
 	subsystemLruCrawler = "lru_crawler"
 	subsystemSlab       = "slab"
 )
@@ -40,722 +45,895 @@ type Exporter struct {
 	timeout   time.Duration
 	logger    *slog.Logger
 	tlsConfig *tls.Config
+	// constLabels are added to every metric of the exporter.
+	constLabels prometheus.Labels
+	poller      *poller
+	derived     *derived
+	latency     *latency
+	drift       *drift
+	restarts    *restarts
+	// memoryLimit holds the bits of limit_maxbytes of the last scrape.
+	memoryLimit atomic.Uint64
+
+	up                         *prometheus.Desc
+	uptime                     *prometheus.Desc
+	time                       *prometheus.Desc
+	version                    *prometheus.Desc
+	rusageUser                 *prometheus.Desc
+	rusageSystem               *prometheus.Desc
+	bytesRead                  *prometheus.Desc
+	bytesWritten               *prometheus.Desc
+	currentConnections         *prometheus.Desc
+	maxConnections             *prometheus.Desc
+	connectionsTotal           *prometheus.Desc
+	rejectedConnections        *prometheus.Desc
+	connsYieldedTotal          *prometheus.Desc
+	listenerDisabledTotal      *prometheus.Desc
+	currentBytes               *prometheus.Desc
+	limitBytes                 *prometheus.Desc
+	commands                   *prometheus.Desc
+	items                      *prometheus.Desc
+	itemsTotal                 *prometheus.Desc
+	evictions                  *prometheus.Desc
+	reclaimed                  *prometheus.Desc
+	itemStoreTooLarge          *prometheus.Desc
+	itemStoreNoMemory          *prometheus.Desc
+	lruCrawlerEnabled          *prometheus.Desc
+	lruCrawlerSleep            *prometheus.Desc
+	lruCrawlerMaxItems         *prometheus.Desc
+	lruMaintainerThread        *prometheus.Desc
+	lruHotPercent              *prometheus.Desc
+	lruWarmPercent             *prometheus.Desc
+	lruHotMaxAgeFactor         *prometheus.Desc
+	lruWarmMaxAgeFactor        *prometheus.Desc
+	lruCrawlerStarts           *prometheus.Desc
+	lruCrawlerReclaimed        *prometheus.Desc
+	lruCrawlerItemsChecked     *prometheus.Desc
+	lruCrawlerMovesToCold      *prometheus.Desc
+	lruCrawlerMovesToWarm      *prometheus.Desc
+	lruCrawlerMovesWithinLru   *prometheus.Desc
+	directReclaims             *prometheus.Desc
+	malloced                   *prometheus.Desc
+	itemsNumber                *prometheus.Desc
+	itemsAge                   *prometheus.Desc
+	itemsCrawlerReclaimed      *prometheus.Desc
+	itemsEvicted               *prometheus.Desc
+	itemsEvictedNonzero        *prometheus.Desc
+	itemsEvictedTime           *prometheus.Desc
+	itemsEvictedUnfetched      *prometheus.Desc
+	itemsExpiredUnfetched      *prometheus.Desc
+	itemsOutofmemory           *prometheus.Desc
+	itemsReclaimed             *prometheus.Desc
+	itemsTailrepairs           *prometheus.Desc
+	itemsMovesToCold           *prometheus.Desc
+	itemsMovesToWarm           *prometheus.Desc
+	itemsMovesWithinLru        *prometheus.Desc
+	itemsHot                   *prometheus.Desc
+	itemsWarm                  *prometheus.Desc
+	itemsCold                  *prometheus.Desc
+	itemsTemporary             *prometheus.Desc
+	itemsAgeOldestHot          *prometheus.Desc
+	itemsAgeOldestWarm         *prometheus.Desc
+	itemsLruHits               *prometheus.Desc
+	slabsChunkSize             *prometheus.Desc
+	slabsChunksPerPage         *prometheus.Desc
+	slabsCurrentPages          *prometheus.Desc
+	slabsCurrentChunks         *prometheus.Desc
+	slabsChunksUsed            *prometheus.Desc
+	slabsChunksFree            *prometheus.Desc
+	slabsChunksFreeEnd         *prometheus.Desc
+	slabsMemRequested          *prometheus.Desc
+	slabsCommands              *prometheus.Desc
+	slabReassignRescues        *prometheus.Desc
+	slabReassignEvictionsNomem *prometheus.Desc
+	slabReassignInlineReclaim  *prometheus.Desc
+	slabReassignBusyItems      *prometheus.Desc
+	slabReassignBusyDeletes    *prometheus.Desc
+	slabReassignRunning        *prometheus.Desc
+	slabsMoved                 *prometheus.Desc
+	slabGlobalPagePool         *prometheus.Desc
+	slabReassignEnabled        *prometheus.Desc
+	slabAutomove               *prometheus.Desc
+	slabAutomoveRatio          *prometheus.Desc
+	slabAutomoveWindow         *prometheus.Desc
+	slabPressure               *prometheus.Desc
+	slabChunkMax               *prometheus.Desc
+	extstoreCompactLost        *prometheus.Desc
+	extstoreCompactRescues     *prometheus.Desc
+	extstoreCompactSkipped     *prometheus.Desc
+	extstorePageAllocs         *prometheus.Desc
+	extstorePageEvictions      *prometheus.Desc
+	extstorePageReclaims       *prometheus.Desc
+	extstorePagesFree          *prometheus.Desc
+	extstorePagesUsed          *prometheus.Desc
+	extstoreObjectsEvicted     *prometheus.Desc
+	extstoreObjectsRead        *prometheus.Desc
+	extstoreObjectsWritten     *prometheus.Desc
+	extstoreObjectsUsed        *prometheus.Desc
+	extstoreBytesEvicted       *prometheus.Desc
+	extstoreBytesWritten       *prometheus.Desc
+	extstoreBytesRead          *prometheus.Desc
+	extstoreBytesUsed          *prometheus.Desc
+	extstoreBytesLimit         *prometheus.Desc
+	extstoreBytesFragmented    *prometheus.Desc
+	extstoreIOQueueDepth       *prometheus.Desc
+	acceptingConnections       *prometheus.Desc
+	proxyConnRequests          *prometheus.Desc
+	proxyConnErrors            *prometheus.Desc
+	proxyConnOOM               *prometheus.Desc
+	proxyReqActive             *prometheus.Desc
+	proxyConfigReloads         *prometheus.Desc
+	proxyConfigReloadFails     *prometheus.Desc
+	proxyConfigCronRuns        *prometheus.Desc
+	proxyConfigCronFails       *prometheus.Desc
+	proxyBackendTotal          *prometheus.Desc
+	proxyBackendMarkedBad      *prometheus.Desc
+	proxyBackendFailed         *prometheus.Desc
+	proxyRequestFailedDepth    *prometheus.Desc
+	roundRobinFallback         *prometheus.Desc
+	unexpectedNapiIDs          *prometheus.Desc
+	lastScrapeTimestamp        *prometheus.Desc
+	lastScrapeStale            *prometheus.Desc
+	settingsValue              *prometheus.Desc
+	settingsInfo               *prometheus.Desc
+	featureEnabled             *prometheus.Desc
+	itemSizes                  *prometheus.Desc
+}
 
-	up                       *prometheus.Desc
-	uptime                   *prometheus.Desc
-	time                     *prometheus.Desc
-	version                  *prometheus.Desc
-	rusageUser               *prometheus.Desc
-	rusageSystem             *prometheus.Desc
-	bytesRead                *prometheus.Desc
-	bytesWritten             *prometheus.Desc
-	currentConnections       *prometheus.Desc
-	maxConnections           *prometheus.Desc
-	connectionsTotal         *prometheus.Desc
-	rejectedConnections      *prometheus.Desc
-	connsYieldedTotal        *prometheus.Desc
-	listenerDisabledTotal    *prometheus.Desc
-	currentBytes             *prometheus.Desc
-	limitBytes               *prometheus.Desc
-	commands                 *prometheus.Desc
-	items                    *prometheus.Desc
-	itemsTotal               *prometheus.Desc
-	evictions                *prometheus.Desc
-	reclaimed                *prometheus.Desc
-	itemStoreTooLarge        *prometheus.Desc
-	itemStoreNoMemory        *prometheus.Desc
-	lruCrawlerEnabled        *prometheus.Desc
-	lruCrawlerSleep          *prometheus.Desc
-	lruCrawlerMaxItems       *prometheus.Desc
-	lruMaintainerThread      *prometheus.Desc
-	lruHotPercent            *prometheus.Desc
-	lruWarmPercent           *prometheus.Desc
-	lruHotMaxAgeFactor       *prometheus.Desc
-	lruWarmMaxAgeFactor      *prometheus.Desc
-	lruCrawlerStarts         *prometheus.Desc
-	lruCrawlerReclaimed      *prometheus.Desc
-	lruCrawlerItemsChecked   *prometheus.Desc
-	lruCrawlerMovesToCold    *prometheus.Desc
-	lruCrawlerMovesToWarm    *prometheus.Desc
-	lruCrawlerMovesWithinLru *prometheus.Desc
-	directReclaims           *prometheus.Desc
-	malloced                 *prometheus.Desc
-	itemsNumber              *prometheus.Desc
-	itemsAge                 *prometheus.Desc
-	itemsCrawlerReclaimed    *prometheus.Desc
-	itemsEvicted             *prometheus.Desc
-	itemsEvictedNonzero      *prometheus.Desc
-	itemsEvictedTime         *prometheus.Desc
-	itemsEvictedUnfetched    *prometheus.Desc
-	itemsExpiredUnfetched    *prometheus.Desc
-	itemsOutofmemory         *prometheus.Desc
-	itemsReclaimed           *prometheus.Desc
-	itemsTailrepairs         *prometheus.Desc
-	itemsMovesToCold         *prometheus.Desc
-	itemsMovesToWarm         *prometheus.Desc
-	itemsMovesWithinLru      *prometheus.Desc
-	itemsHot                 *prometheus.Desc
-	itemsWarm                *prometheus.Desc
-	itemsCold                *prometheus.Desc
-	itemsTemporary           *prometheus.Desc
-	itemsAgeOldestHot        *prometheus.Desc
-	itemsAgeOldestWarm       *prometheus.Desc
-	itemsLruHits             *prometheus.Desc
-	slabsChunkSize           *prometheus.Desc
-	slabsChunksPerPage       *prometheus.Desc
-	slabsCurrentPages        *prometheus.Desc
-	slabsCurrentChunks       *prometheus.Desc
-	slabsChunksUsed          *prometheus.Desc
-	slabsChunksFree          *prometheus.Desc
-	slabsChunksFreeEnd       *prometheus.Desc
-	slabsMemRequested        *prometheus.Desc
-	slabsCommands            *prometheus.Desc
-	extstoreCompactLost      *prometheus.Desc
-	extstoreCompactRescues   *prometheus.Desc
-	extstoreCompactSkipped   *prometheus.Desc
-	extstorePageAllocs       *prometheus.Desc
-	extstorePageEvictions    *prometheus.Desc
-	extstorePageReclaims     *prometheus.Desc
-	extstorePagesFree        *prometheus.Desc
-	extstorePagesUsed        *prometheus.Desc
-	extstoreObjectsEvicted   *prometheus.Desc
-	extstoreObjectsRead      *prometheus.Desc
-	extstoreObjectsWritten   *prometheus.Desc
-	extstoreObjectsUsed      *prometheus.Desc
-	extstoreBytesEvicted     *prometheus.Desc
-	extstoreBytesWritten     *prometheus.Desc
-	extstoreBytesRead        *prometheus.Desc
-	extstoreBytesUsed        *prometheus.Desc
-	extstoreBytesLimit       *prometheus.Desc
-	extstoreBytesFragmented  *prometheus.Desc
-	extstoreIOQueueDepth     *prometheus.Desc
-	acceptingConnections     *prometheus.Desc
-	proxyConnRequests        *prometheus.Desc
-	proxyConnErrors          *prometheus.Desc
-	proxyConnOOM             *prometheus.Desc
-	proxyReqActive           *prometheus.Desc
-	proxyConfigReloads       *prometheus.Desc
-	proxyConfigReloadFails   *prometheus.Desc
-	proxyConfigCronRuns      *prometheus.Desc
-	proxyConfigCronFails     *prometheus.Desc
-	proxyBackendTotal        *prometheus.Desc
-	proxyBackendMarkedBad    *prometheus.Desc
-	proxyBackendFailed       *prometheus.Desc
-	proxyRequestFailedDepth  *prometheus.Desc
-	roundRobinFallback       *prometheus.Desc
-	unexpectedNapiIDs        *prometheus.Desc
+// Option configures an exporter created with NewWithOpts.
+type Option func(*options)
+
+type options struct {
+	constLabels prometheus.Labels
+}
+
+// WithConstLabels adds labels to every metric of the exporter.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(o *options) {
+		o.constLabels = labels
+	}
 }
 
 // New returns an initialized exporter.
 func New(server string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config) *Exporter {
+	return NewWithOpts(server, timeout, logger, tlsConfig)
+}
+
+// NewWithOpts returns an initialized exporter configured by opts.
+func NewWithOpts(server string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config, opts ...Option) *Exporter {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	constLabels := o.constLabels
 	return &Exporter{
-		address:   server,
-		timeout:   timeout,
-		logger:    logger,
-		tlsConfig: tlsConfig,
+		address:     server,
+		timeout:     timeout,
+		logger:      logger,
+		tlsConfig:   tlsConfig,
+		constLabels: constLabels,
+		restarts:    newRestarts(constLabels),
 		up: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "up"),
 			"Could the memcached server be reached.",
 			nil,
-			nil,
+			constLabels,
 		),
 		uptime: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "uptime_seconds"),
 			"Number of seconds since the server started.",
 			nil,
-			nil,
+			constLabels,
 		),
 		time: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "time_seconds"),
 			"current UNIX time according to the server.",
 			nil,
-			nil,
+			constLabels,
 		),
 		version: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "version"),
 			"The version of this memcached server.",
 			[]string{"version"},
-			nil,
+			constLabels,
 		),
 		rusageUser: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "process_user_cpu_seconds_total"),
 			"Accumulated user time for this process.",
 			nil,
-			nil,
+			constLabels,
 		),
 		rusageSystem: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "process_system_cpu_seconds_total"),
 			"Accumulated system time for this process.",
 			nil,
-			nil,
+			constLabels,
 		),
 		bytesRead: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "read_bytes_total"),
 			"Total number of bytes read by this server from network.",
 			nil,
-			nil,
+			constLabels,
 		),
 		bytesWritten: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "written_bytes_total"),
 			"Total number of bytes sent by this server to network.",
 			nil,
-			nil,
+			constLabels,
 		),
 		currentConnections: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "current_connections"),
 			"Current number of open connections.",
 			nil,
-			nil,
+			constLabels,
 		),
 		maxConnections: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "max_connections"),
 			"Maximum number of clients allowed.",
 			nil,
-			nil,
+			constLabels,
 		),
 		connectionsTotal: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "connections_total"),
 			"Total number of connections opened since the server started running.",
 			nil,
-			nil,
+			constLabels,
 		),
 		rejectedConnections: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "connections_rejected_total"),
 			"Total number of connections rejected due to hitting the memcached's -c limit in maxconns_fast mode.",
 			nil,
-			nil,
+			constLabels,
 		),
 		connsYieldedTotal: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "connections_yielded_total"),
 			"Total number of connections yielded running due to hitting the memcached's -R limit.",
 			nil,
-			nil,
+			constLabels,
 		),
 		listenerDisabledTotal: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "connections_listener_disabled_total"),
 			"Number of times that memcached has hit its connections limit and disabled its listener.",
 			nil,
-			nil,
+			constLabels,
 		),
 		currentBytes: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "current_bytes"),
 			"Current number of bytes used to store items.",
 			nil,
-			nil,
+			constLabels,
 		),
 		limitBytes: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "limit_bytes"),
 			"Number of bytes this server is allowed to use for storage.",
 			nil,
-			nil,
+			constLabels,
 		),
 		commands: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "commands_total"),
 			"Total number of all requests broken down by command (get, set, etc.) and status.",
 			[]string{"command", "status"},
-			nil,
+			constLabels,
 		),
 		items: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "current_items"),
 			"Current number of items stored by this instance.",
 			nil,
-			nil,
+			constLabels,
 		),
 		itemsTotal: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "items_total"),
 			"Total number of items stored during the life of this instance.",
 			nil,
-			nil,
+			constLabels,
 		),
 		evictions: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "items_evicted_total"),
 			"Total number of valid items removed from cache to free memory for new items.",
 			nil,
-			nil,
+			constLabels,
 		),
 		reclaimed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "items_reclaimed_total"),
 			"Total number of times an entry was stored using memory from an expired entry.",
 			nil,
-			nil,
+			constLabels,
 		),
 		itemStoreTooLarge: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "item_too_large_total"),
 			"The number of times an item exceeded the max-item-size when being stored.",
 			nil,
-			nil,
+			constLabels,
 		),
 		itemStoreNoMemory: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "item_no_memory_total"),
 			"The number of times an item could not be stored due to no more memory.",
 			nil,
-			nil,
+			constLabels,
 		),
 		directReclaims: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "direct_reclaims_total"),
 			"Times worker threads had to directly reclaim or evict items.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerEnabled: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "enabled"),
 			"Whether the LRU crawler is enabled.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerSleep: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "sleep"),
 			"Microseconds to sleep between LRU crawls.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerMaxItems: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "to_crawl"),
 			"Max items to crawl per slab per run.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruMaintainerThread: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "maintainer_thread"),
 			"Split LRU mode and background threads.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruHotPercent: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "hot_percent"),
 			"Percent of slab memory reserved for HOT LRU.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruWarmPercent: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "warm_percent"),
 			"Percent of slab memory reserved for WARM LRU.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruHotMaxAgeFactor: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "hot_max_factor"),
 			"Set idle age of HOT LRU to COLD age * this",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruWarmMaxAgeFactor: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "warm_max_factor"),
 			"Set idle age of WARM LRU to COLD age * this",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerStarts: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "starts_total"),
 			"Times an LRU crawler was started.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerReclaimed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "reclaimed_total"),
 			"Total items freed by LRU Crawler.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerItemsChecked: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "items_checked_total"),
 			"Total items examined by LRU Crawler.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerMovesToCold: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "moves_to_cold_total"),
 			"Total number of items moved from HOT/WARM to COLD LRU's.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerMovesToWarm: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "moves_to_warm_total"),
 			"Total number of items moved from COLD to WARM LRU.",
 			nil,
-			nil,
+			constLabels,
 		),
 		lruCrawlerMovesWithinLru: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemLruCrawler, "moves_within_lru_total"),
 			"Total number of items reshuffled within HOT or WARM LRU's.",
 			nil,
-			nil,
+			constLabels,
 		),
 		malloced: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "malloced_bytes"),
 			"Number of bytes of memory allocated to slab pages.",
 			nil,
-			nil,
+			constLabels,
 		),
 		itemsNumber: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "current_items"),
 			"Number of items currently stored in this slab class.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsAge: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_age_seconds"),
 			"Number of seconds the oldest item has been in the slab class.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsCrawlerReclaimed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_crawler_reclaimed_total"),
 			"Number of items freed by the LRU Crawler.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsEvicted: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_evicted_total"),
 			"Total number of times an item had to be evicted from the LRU before it expired.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsEvictedNonzero: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_evicted_nonzero_total"),
 			"Total number of times an item which had an explicit expire time set had to be evicted from the LRU before it expired.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsEvictedTime: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_evicted_time_seconds"),
 			"Seconds since the last access for the most recent item evicted from this class.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsEvictedUnfetched: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_evicted_unfetched_total"),
 			"Total nmber of items evicted and never fetched.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsExpiredUnfetched: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_expired_unfetched_total"),
 			"Total number of valid items evicted from the LRU which were never touched after being set.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsOutofmemory: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_outofmemory_total"),
 			"Total number of items for this slab class that have triggered an out of memory error.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsReclaimed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_reclaimed_total"),
 			"Total number of items reclaimed.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsTailrepairs: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_tailrepairs_total"),
 			"Total number of times the entries for a particular ID need repairing.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsMovesToCold: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_moves_to_cold"),
 			"Number of items moved from HOT or WARM into COLD.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsMovesToWarm: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_moves_to_warm"),
 			"Number of items moves from COLD into WARM.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsMovesWithinLru: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "items_moves_within_lru"),
 			"Number of times active items were bumped within HOT or WARM.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsHot: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "hot_items"),
 			"Number of items presently stored in the HOT LRU.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsWarm: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "warm_items"),
 			"Number of items presently stored in the WARM LRU.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsCold: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "cold_items"),
 			"Number of items presently stored in the COLD LRU.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsTemporary: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "temporary_items"),
 			"Number of items presently stored in the TEMPORARY LRU.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsAgeOldestHot: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "hot_age_seconds"),
 			"Age of the oldest item in HOT LRU.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsAgeOldestWarm: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "warm_age_seconds"),
 			"Age of the oldest item in HOT LRU.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		itemsLruHits: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "lru_hits_total"),
 			"Number of get_hits to the LRU.",
 			[]string{"slab", "lru"},
-			nil,
+			constLabels,
 		),
 		slabsChunkSize: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "chunk_size_bytes"),
 			"Number of bytes allocated to each chunk within this slab class.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		slabsChunksPerPage: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "chunks_per_page"),
 			"Number of chunks within a single page for this slab class.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		slabsCurrentPages: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "current_pages"),
 			"Number of pages allocated to this slab class.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		slabsCurrentChunks: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "current_chunks"),
 			"Number of chunks allocated to this slab class.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		slabsChunksUsed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "chunks_used"),
 			"Number of chunks allocated to an item.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		slabsChunksFree: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "chunks_free"),
 			"Number of chunks not yet allocated items.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		slabsChunksFreeEnd: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "chunks_free_end"),
 			"Number of free chunks at the end of the last allocated page.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		slabsMemRequested: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "mem_requested_bytes"),
 			"Number of bytes of memory actual items take up within a slab.",
 			[]string{"slab"},
-			nil,
+			constLabels,
 		),
 		slabsCommands: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystemSlab, "commands_total"),
 			"Total number of all requests broken down by command (get, set, etc.) and status per slab.",
 			[]string{"slab", "command", "status"},
+			constLabels,
+		),
+		slabReassignRescues: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "reassign_rescues_total"),
+			"Total number of items rescued from a slab page being moved.",
+			nil,
+			constLabels,
+		),
+		slabReassignEvictionsNomem: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "reassign_evictions_nomem_total"),
+			"Total number of valid items evicted during a slab page move because the slab class had no free memory.",
+			nil,
+			constLabels,
+		),
+		slabReassignInlineReclaim: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "reassign_inline_reclaim_total"),
+			"Total number of times the slab page mover reclaimed memory from the chunk freelist.",
+			nil,
+			constLabels,
+		),
+		slabReassignBusyItems: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "reassign_busy_items_total"),
+			"Total number of items which were busy during a slab page move, requiring a retry.",
+			nil,
+			constLabels,
+		),
+		slabReassignBusyDeletes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "reassign_busy_deletes_total"),
+			"Total number of items which were deleted while busy during a slab page move.",
 			nil,
+			constLabels,
+		),
+		slabReassignRunning: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "reassign_running"),
+			"Whether a slab page move is in progress.",
+			nil,
+			constLabels,
+		),
+		slabsMoved: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "pages_moved_total"),
+			"Total number of slab pages moved between slab classes.",
+			nil,
+			constLabels,
+		),
+		slabGlobalPagePool: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "global_page_pool_pages"),
+			"Number of slab pages in the global pool, available for reassignment to any slab class.",
+			nil,
+			constLabels,
+		),
+		slabReassignEnabled: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "reassign_enabled"),
+			"Whether slab page reassignment is enabled.",
+			nil,
+			constLabels,
+		),
+		slabAutomove: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "automove_mode"),
+			"Slab automove mode, 0 disables automatic page moves.",
+			nil,
+			constLabels,
+		),
+		slabAutomoveRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "automove_ratio"),
+			"Ratio of free chunks a slab class must exceed for automove to take pages from it.",
+			nil,
+			constLabels,
+		),
+		slabAutomoveWindow: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "automove_window"),
+			"Number of automove intervals free chunks are averaged over.",
+			nil,
+			constLabels,
+		),
+		slabPressure: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "pressure_ratio"),
+			"Evictions per page of the slab class, weighted by its share of used chunks, relative to the average of all slab classes. Values above 1 mark slab classes starved of pages.",
+			[]string{"slab"},
+			constLabels,
+		),
+		slabChunkMax: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystemSlab, "chunk_max_bytes"),
+			"Maximum chunk size, larger items are chained.",
+			nil,
+			constLabels,
 		),
 		extstoreCompactLost: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_compact_lost_total"),
 			"Total number of items lost because they were locked during extstore compaction.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreCompactRescues: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_compact_rescued_total"),
 			"Total number of items moved to a new page during extstore compaction,",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreCompactSkipped: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_compact_skipped_total"),
 			"Total number of items dropped due to inactivity during extstore compaction.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstorePageAllocs: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_pages_allocated_total"),
 			"Total number of times a page was allocated in extstore.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstorePageEvictions: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_pages_evicted_total"),
 			"Total number of times a page was evicted from extstore.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstorePageReclaims: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_pages_reclaimed_total"),
 			"Total number of times an empty extstore page was freed.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstorePagesFree: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_pages_free"),
 			"Number of extstore pages not yet containing any items.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstorePagesUsed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_pages_used"),
 			"Number of extstore pages containing at least one item.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreObjectsEvicted: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_objects_evicted_total"),
 			"Total number of items evicted from extstore to free up space.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreObjectsRead: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_objects_read_total"),
 			"Total number of items read from extstore.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreObjectsWritten: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_objects_written_total"),
 			"Total number of items written to extstore.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreObjectsUsed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_objects_used"),
 			"Number of items stored in extstore.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreBytesEvicted: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_bytes_evicted_total"),
 			"Total number of bytes evicted from extstore to free up space.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreBytesWritten: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_bytes_written_total"),
 			"Total number of bytes written to extstore.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreBytesRead: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_bytes_read_total"),
 			"Total number of bytes read from extstore.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreBytesUsed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_bytes_used"),
 			"Current number of bytes used to store items in extstore.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreBytesFragmented: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_bytes_fragmented"),
 			"Current number of bytes in extstore pages allocated but not used to store an object.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreBytesLimit: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_bytes_limit"),
 			"Number of bytes of external storage allocated for this server.",
 			nil,
-			nil,
+			constLabels,
 		),
 		extstoreIOQueueDepth: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "extstore_io_queue_depth"),
 			"Number of items in the I/O queue waiting to be processed.",
 			nil,
-			nil,
+			constLabels,
 		),
 		acceptingConnections: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "accepting_connections"),
 			"The Memcached server is currently accepting new connections.",
 			nil,
-			nil,
+			constLabels,
 		),
 		proxyConnRequests: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_conn_requests_total"),
 			"Total number of times the proxy opened a backend connection.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyConnErrors: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_conn_errors_total"),
 			"Total number of backend connection errors in proxy mode.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyConnOOM: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_conn_oom_total"),
 			"Total number of times the proxy ran out of memory allocating a connection.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyReqActive: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_req_active"),
 			"Number of in-flight requests currently forwarded by the proxy.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyConfigReloads: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_config_reloads_total"),
 			"Total attempts to reload the proxy configuration.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyConfigReloadFails: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_config_reload_fails_total"),
 			"Total failed attempts to reload the proxy configuration.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyConfigCronRuns: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_config_cron_runs_total"),
 			"Total times the proxy’s Lua cron hooks have run.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyConfigCronFails: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_config_cron_fails_total"),
 			"Total errors from the proxy’s Lua cron hooks.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyBackendTotal: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_backend_total"),
 			"Number of backend servers configured in proxy mode.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyBackendMarkedBad: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_backend_marked_bad_total"),
 			"Total times a backend was marked unhealthy by the proxy.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyBackendFailed: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_backend_failed"),
 			"Number of backends currently in a failed state.",
-			nil, nil,
+			nil, constLabels,
 		),
 		proxyRequestFailedDepth: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "proxy_request_failed_depth_total"),
 			"Total requests dropped due to backend depth limits.",
-			nil, nil,
+			nil, constLabels,
 		),
 		roundRobinFallback: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "round_robin_fallback_total"),
 			"Total times the proxy fell back to round-robin routing.",
-			nil, nil,
+			nil, constLabels,
 		),
 		unexpectedNapiIDs: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, "", "unexpected_napi_ids_total"),
 			"Total unexpected internal event-loop IDs seen by the proxy.",
-			nil, nil,
+			nil, constLabels,
+		),
+		lastScrapeTimestamp: prometheus.NewDesc(
+			prometheus.BuildFQName(exporterNamespace, "", "last_scrape_timestamp_seconds"),
+			"Unix time of the last successful background poll of the memcached server.",
+			nil, constLabels,
+		),
+		lastScrapeStale: prometheus.NewDesc(
+			prometheus.BuildFQName(exporterNamespace, "", "last_scrape_stale"),
+			"Whether the served metrics come from an older snapshot because the last background poll failed.",
+			nil, constLabels,
+		),
+		settingsValue: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "settings", "value"),
+			"Value of a numeric setting as reported by stats settings.",
+			[]string{"setting"},
+			constLabels,
+		),
+		settingsInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "settings", "info"),
+			"Value of a non-numeric setting as reported by stats settings.",
+			[]string{"setting", "value"},
+			constLabels,
+		),
+		featureEnabled: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "feature", "enabled"),
+			"Whether a feature is supported and enabled by the server, detected from its version, stats and settings.",
+			[]string{"feature"},
+			constLabels,
+		),
+		itemSizes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "item_size_items"),
+			"Number of items per 32 byte size bucket, only reported when the server tracks item sizes.",
+			[]string{"size"},
+			constLabels,
 		),
 	}
 }
@@ -833,6 +1011,20 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.slabsChunksFreeEnd
 	ch <- e.slabsMemRequested
 	ch <- e.slabsCommands
+	ch <- e.slabReassignRescues
+	ch <- e.slabReassignEvictionsNomem
+	ch <- e.slabReassignInlineReclaim
+	ch <- e.slabReassignBusyItems
+	ch <- e.slabReassignBusyDeletes
+	ch <- e.slabReassignRunning
+	ch <- e.slabsMoved
+	ch <- e.slabGlobalPagePool
+	ch <- e.slabReassignEnabled
+	ch <- e.slabAutomove
+	ch <- e.slabAutomoveRatio
+	ch <- e.slabAutomoveWindow
+	ch <- e.slabPressure
+	ch <- e.slabChunkMax
 	ch <- e.extstoreCompactLost
 	ch <- e.extstoreCompactRescues
 	ch <- e.extstoreCompactSkipped
@@ -867,43 +1059,168 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.proxyRequestFailedDepth
 	ch <- e.roundRobinFallback
 	ch <- e.unexpectedNapiIDs
+	ch <- e.lastScrapeTimestamp
+	ch <- e.lastScrapeStale
+	ch <- e.settingsValue
+	ch <- e.settingsInfo
+	ch <- e.featureEnabled
+	ch <- e.itemSizes
+	e.restarts.describe(ch)
+	if e.derived != nil {
+		e.derived.describe(ch)
+	}
+	if e.drift != nil {
+		e.drift.describe(ch)
+	}
+	if e.latency != nil {
+		e.latency.describe(ch)
+	}
 }
 
 // Collect fetches the statistics from the configured memcached server, and
 // delivers them as Prometheus metrics. It implements prometheus.Collector.
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	if e.poller != nil {
+		e.poller.collect(ch)
+		return
+	}
+	e.collect(ch)
+}
+
+// MemoryLimit returns limit_maxbytes of the server as of the last scrape, or
+// 0 before the server was first scraped. Collectors of the memcached process
+// use it instead of querying the server again.
+func (e *Exporter) MemoryLimit() float64 {
+	return math.Float64frombits(e.memoryLimit.Load())
+}
+
+// collect queries the memcached server and reports whether it was up.
+func (e *Exporter) collect(ch chan<- prometheus.Metric) bool {
+	var t *timer
+	if e.latency != nil {
+		t = e.latency.newTimer(e.address)
+		defer e.latency.collect(ch)
+	}
+	logger := t.logger(e.logger)
+
 	c, err := memcache.New(e.address)
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(e.up, prometheus.GaugeValue, 0)
-		e.logger.Error("Failed to connect to memcached", "err", err)
-		return
+		logger.Error("Failed to connect to memcached", "err", err)
+		return false
 	}
 	c.Timeout = e.timeout
 	c.TlsConfig = e.tlsConfig
 
+	var conn *client.Conn
+	if t != nil {
+		// A failed connection is logged by the requests below.
+		if conn, err = t.dial(e.address, e.timeout, e.tlsConfig); err == nil {
+			defer conn.Close()
+		}
+	}
+
 	up := float64(1)
-	stats, err := c.Stats()
-	if err != nil {
-		e.logger.Error("Failed to collect stats from memcached", "err", err)
+	// The client connects on the first request, so the stats request
+	// includes connecting to the server.
+	var stats map[net.Addr]memcache.Stats
+	if err := t.time(operationStats, func() (err error) {
+		stats, err = c.Stats()
+		return err
+	}); err != nil {
+		logger.Error("Failed to collect stats from memcached", "err", err)
 		up = 0
 	}
-	statsSettings, err := c.StatsSettings()
-	if err != nil {
-		e.logger.Error("Could not query stats settings", "err", err)
+	var statsSettings map[net.Addr]map[string]string
+	if err := t.time(operationStatsSettings, func() (err error) {
+		statsSettings, err = c.StatsSettings()
+		return err
+	}); err != nil {
+		logger.Error("Could not query stats settings", "err", err)
 		up = 0
 	}
 
-	if err := e.parseStats(ch, stats); err != nil {
+	features := detectFeatures(stats, statsSettings)
+	if len(stats) > 0 {
+		for _, f := range allFeatures {
+			v := 0.
+			if features[f] {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(e.featureEnabled, prometheus.GaugeValue, v, f)
+		}
+	}
+
+	if err := e.parseStats(ch, stats, features); err != nil {
 		up = 0
 	}
+	if features[FeatureSizes] {
+		if err := t.time(operationStatsSizes, func() error { return e.collectSizes(ch, conn) }); err != nil {
+			logger.Error("Could not query stats sizes", "err", err)
+			up = 0
+		}
+	}
 	if err := e.parseStatsSettings(ch, statsSettings); err != nil {
 		up = 0
 	}
+	for _, t := range stats {
+		e.collectSlabPressure(ch, t)
+		e.restarts.update(t.Stats)
+		if limit, err := sum(t.Stats, "limit_maxbytes"); err == nil {
+			e.memoryLimit.Store(math.Float64bits(limit))
+		}
+	}
+	e.restarts.collect(ch)
+	if e.derived != nil {
+		e.derived.collect(ch, stats, statsSettings)
+	}
+	if e.drift != nil {
+		e.drift.collect(ch, stats, statsSettings)
+	}
 
 	ch <- prometheus.MustNewConstMetric(e.up, prometheus.GaugeValue, up)
+	logger.Debug("Scraped memcached", "target", e.address, "up", up)
+	return up == 1
 }
 
-func (e *Exporter) parseStats(ch chan<- prometheus.Metric, stats map[net.Addr]memcache.Stats) error {
+// collectSlabPressure scores how starved each slab class is of pages. A
+// class which evicts a lot for the pages it has while having few free chunks
+// would benefit most from slab_automove giving it more pages.
+func (e *Exporter) collectSlabPressure(ch chan<- prometheus.Metric, t memcache.Stats) {
+	pressure := map[int]float64{}
+	var total float64
+	for slab, v := range t.Slabs {
+		pages, err := sum(v, "total_pages")
+		if err != nil || pages == 0 {
+			continue
+		}
+		chunks, chunksErr := sum(v, "total_chunks")
+		free, freeErr := sum(v, "free_chunks")
+		evicted, evictedErr := sum(t.Items[slab], "evicted")
+		if firstError(chunksErr, freeErr) != nil || chunks == 0 {
+			continue
+		}
+		if evictedErr != nil {
+			// Slab classes without items have no items stats.
+			evicted = 0
+		}
+		pressure[slab] = evicted / pages * (1 - free/chunks)
+		total += pressure[slab]
+	}
+	if len(pressure) == 0 {
+		return
+	}
+	mean := total / float64(len(pressure))
+	for slab, p := range pressure {
+		v := 0.
+		if mean > 0 {
+			v = p / mean
+		}
+		ch <- prometheus.MustNewConstMetric(e.slabPressure, prometheus.GaugeValue, v, strconv.Itoa(slab))
+	}
+}
+
+func (e *Exporter) parseStats(ch chan<- prometheus.Metric, stats map[net.Addr]memcache.Stats, features Features) error {
 	// TODO(ts): Clean up and consolidate metric mappings.
 	itemsCounterMetrics := map[string]*prometheus.Desc{
 		"crawler_reclaimed": e.itemsCrawlerReclaimed,
@@ -970,9 +1287,8 @@ func (e *Exporter) parseStats(ch chan<- prometheus.Metric, stats map[net.Addr]me
 			parseError = err
 		}
 
-		// extstore stats are only included if extstore is actually active. Take the presence of the
-		// maxbytes key as a signal that they all should be there and do the parsing
-		if _, ok := s["extstore_limit_maxbytes"]; ok {
+		// extstore stats are only included if extstore is actually active.
+		if features[FeatureExtstore] {
 			err = firstError(
 				e.parseAndNewMetric(ch, e.extstoreCompactLost, prometheus.CounterValue, s, "extstore_compact_lost"),
 				e.parseAndNewMetric(ch, e.extstoreCompactRescues, prometheus.CounterValue, s, "extstore_compact_rescues"),
@@ -999,9 +1315,8 @@ func (e *Exporter) parseStats(ch chan<- prometheus.Metric, stats map[net.Addr]me
 			}
 		}
 
-		// proxy stats are only included if memcached server is in proxy mode. Take the presence of the
-		// proxy_backend_total key as a signal that they all should be there and do the parsing
-		if _, ok := s["proxy_backend_total"]; ok {
+		// proxy stats are only included if memcached server is in proxy mode.
+		if features[FeatureProxy] {
 			err = firstError(
 				e.parseAndNewMetric(ch, e.proxyConnRequests, prometheus.CounterValue, s, "proxy_conn_requests"),
 				e.parseAndNewMetric(ch, e.proxyConnErrors, prometheus.CounterValue, s, "proxy_conn_errors"),
@@ -1050,6 +1365,14 @@ func (e *Exporter) parseStats(ch chan<- prometheus.Metric, stats map[net.Addr]me
 			e.parseAndNewMetric(ch, e.lruCrawlerMovesWithinLru, prometheus.CounterValue, s, "moves_within_lru"),
 			e.parseAndNewMetric(ch, e.malloced, prometheus.GaugeValue, s, "total_malloced"),
 			e.parseAndNewMetric(ch, e.acceptingConnections, prometheus.GaugeValue, s, "accepting_conns"),
+			e.parseAndNewMetric(ch, e.slabReassignRescues, prometheus.CounterValue, s, "slab_reassign_rescues"),
+			e.parseAndNewMetric(ch, e.slabReassignEvictionsNomem, prometheus.CounterValue, s, "slab_reassign_evictions_nomem"),
+			e.parseAndNewMetric(ch, e.slabReassignInlineReclaim, prometheus.CounterValue, s, "slab_reassign_inline_reclaim"),
+			e.parseAndNewMetric(ch, e.slabReassignBusyItems, prometheus.CounterValue, s, "slab_reassign_busy_items"),
+			e.parseAndNewMetric(ch, e.slabReassignBusyDeletes, prometheus.CounterValue, s, "slab_reassign_busy_deletes"),
+			e.parseAndNewMetric(ch, e.slabReassignRunning, prometheus.GaugeValue, s, "slab_reassign_running"),
+			e.parseAndNewMetric(ch, e.slabsMoved, prometheus.CounterValue, s, "slabs_moved"),
+			e.parseAndNewMetric(ch, e.slabGlobalPagePool, prometheus.GaugeValue, s, "slab_global_page_pool"),
 		)
 		if err != nil {
 			parseError = err
@@ -1133,10 +1456,20 @@ func (e *Exporter) parseStats(ch chan<- prometheus.Metric, stats map[net.Addr]me
 func (e *Exporter) parseStatsSettings(ch chan<- prometheus.Metric, statsSettings map[net.Addr]map[string]string) error {
 	var parseError error
 	for _, settings := range statsSettings {
-		if err := e.parseAndNewMetric(ch, e.maxConnections, prometheus.GaugeValue, settings, "maxconns"); err != nil {
+		err := firstError(
+			e.parseAndNewMetric(ch, e.maxConnections, prometheus.GaugeValue, settings, "maxconns"),
+			e.parseBoolAndNewMetric(ch, e.slabReassignEnabled, prometheus.GaugeValue, settings, "slab_reassign"),
+			e.parseAndNewMetric(ch, e.slabAutomove, prometheus.GaugeValue, settings, "slab_automove"),
+			e.parseAndNewMetric(ch, e.slabAutomoveRatio, prometheus.GaugeValue, settings, "slab_automove_ratio"),
+			e.parseAndNewMetric(ch, e.slabAutomoveWindow, prometheus.GaugeValue, settings, "slab_automove_window"),
+			e.parseAndNewMetric(ch, e.slabChunkMax, prometheus.GaugeValue, settings, "slab_chunk_max"),
+		)
+		if err != nil {
 			parseError = err
 		}
 
+		e.parseAllSettings(ch, settings)
+
 		if v, ok := settings["lru_crawler"]; ok && v == "yes" {
 			err := firstError(
 				e.parseBoolAndNewMetric(ch, e.lruCrawlerEnabled, prometheus.GaugeValue, settings, "lru_crawler"),
@@ -1156,6 +1489,18 @@ func (e *Exporter) parseStatsSettings(ch chan<- prometheus.Metric, statsSettings
 	return parseError
 }
 
+// parseAllSettings exports every numeric setting as a gauge and all other
+// settings as an info metric carrying their value.
+func (e *Exporter) parseAllSettings(ch chan<- prometheus.Metric, settings map[string]string) {
+	for k, v := range settings {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(e.settingsValue, prometheus.GaugeValue, f, k)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(e.settingsInfo, prometheus.GaugeValue, 1, k, v)
+	}
+}
+
 func (e *Exporter) parseAndNewMetric(ch chan<- prometheus.Metric, desc *prometheus.Desc, valueType prometheus.ValueType, stats map[string]string, key string, labelValues ...string) error {
 	return e.extractValueAndNewMetric(ch, desc, valueType, parse, stats, key, labelValues...)
 }
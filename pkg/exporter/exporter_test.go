@@ -15,11 +15,15 @@ package exporter
 
 import (
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
 )
 
 func TestParseStatsSettings(t *testing.T) {
@@ -76,6 +80,108 @@ func TestParseStatsSettings(t *testing.T) {
 	})
 }
 
+func TestSlabReassign(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		s := testStats()
+		s.Stats["slab_reassign_rescues"] = "5"
+		s.Stats["slab_reassign_busy_items"] = "2"
+		s.Stats["slabs_moved"] = "3"
+		s.Stats["slab_global_page_pool"] = "1"
+		s.Settings["slab_reassign"] = "yes"
+		s.Settings["slab_automove"] = "1"
+		return s
+	}))
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+
+	want := `
+# HELP memcached_slab_automove_mode Slab automove mode, 0 disables automatic page moves.
+# TYPE memcached_slab_automove_mode gauge
+memcached_slab_automove_mode 1
+# HELP memcached_slab_global_page_pool_pages Number of slab pages in the global pool, available for reassignment to any slab class.
+# TYPE memcached_slab_global_page_pool_pages gauge
+memcached_slab_global_page_pool_pages 1
+# HELP memcached_slab_pages_moved_total Total number of slab pages moved between slab classes.
+# TYPE memcached_slab_pages_moved_total counter
+memcached_slab_pages_moved_total 3
+# HELP memcached_slab_reassign_busy_items_total Total number of items which were busy during a slab page move, requiring a retry.
+# TYPE memcached_slab_reassign_busy_items_total counter
+memcached_slab_reassign_busy_items_total 2
+# HELP memcached_slab_reassign_enabled Whether slab page reassignment is enabled.
+# TYPE memcached_slab_reassign_enabled gauge
+memcached_slab_reassign_enabled 1
+# HELP memcached_slab_reassign_rescues_total Total number of items rescued from a slab page being moved.
+# TYPE memcached_slab_reassign_rescues_total counter
+memcached_slab_reassign_rescues_total 5
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want),
+		"memcached_slab_automove_mode", "memcached_slab_global_page_pool_pages", "memcached_slab_pages_moved_total",
+		"memcached_slab_reassign_busy_items_total", "memcached_slab_reassign_enabled", "memcached_slab_reassign_rescues_total",
+		"memcached_slab_reassign_evictions_nomem_total"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSlabPressure(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		s := testStats()
+		s.Slabs = map[string]string{
+			"1:total_chunks": "10",
+			"1:free_chunks":  "6",
+			"1:total_pages":  "1",
+			"2:total_chunks": "10",
+			"2:free_chunks":  "0",
+			"2:total_pages":  "2",
+			"3:total_chunks": "10",
+			"3:free_chunks":  "10",
+			"3:total_pages":  "0",
+		}
+		s.Items = map[string]string{
+			"items:1:evicted": "10",
+			"items:2:evicted": "40",
+		}
+		return s
+	}))
+	// The score is exported without the derived metrics.
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+
+	want := `
+# HELP memcached_slab_pressure_ratio Evictions per page of the slab class, weighted by its share of used chunks, relative to the average of all slab classes. Values above 1 mark slab classes starved of pages.
+# TYPE memcached_slab_pressure_ratio gauge
+memcached_slab_pressure_ratio{slab="1"} 0.3333333333333333
+memcached_slab_pressure_ratio{slab="2"} 1.6666666666666667
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), "memcached_slab_pressure_ratio"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllSettings(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		s := testStats()
+		s.Settings["growth_factor"] = "1.25"
+		s.Settings["cas_enabled"] = "yes"
+		s.Settings["evictions"] = "on"
+		s.Settings["binding_protocol"] = "auto-negotiate"
+		return s
+	}))
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+
+	want := `
+# HELP memcached_settings_info Value of a non-numeric setting as reported by stats settings.
+# TYPE memcached_settings_info gauge
+memcached_settings_info{setting="binding_protocol",value="auto-negotiate"} 1
+memcached_settings_info{setting="cas_enabled",value="yes"} 1
+memcached_settings_info{setting="evictions",value="on"} 1
+# HELP memcached_settings_value Value of a numeric setting as reported by stats settings.
+# TYPE memcached_settings_value gauge
+memcached_settings_value{setting="growth_factor"} 1.25
+memcached_settings_value{setting="maxconns"} 1024
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), "memcached_settings_info", "memcached_settings_value"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestParseTimeval(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		t.Parallel()
@@ -93,3 +199,55 @@ func TestParseTimeval(t *testing.T) {
 		}
 	})
 }
+
+func TestConstLabels(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(testStats))
+	e := NewWithOpts(srv.Addr, time.Second, promslog.NewNopLogger(), nil, WithConstLabels(prometheus.Labels{"pool": "sessions", "tier": "hot"}))
+	e.EnableDerivedMetrics()
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(e)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(families) == 0 {
+		t.Fatal("no metrics collected")
+	}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["pool"] != "sessions" || labels["tier"] != "hot" {
+				t.Errorf("%s is missing the constant labels: %v", f.GetName(), labels)
+			}
+		}
+	}
+
+	// Labels clashing with a variable label fail registration.
+	for _, name := range []string{"slab", "setting"} {
+		e := NewWithOpts(srv.Addr, time.Second, promslog.NewNopLogger(), nil, WithConstLabels(prometheus.Labels{name: "x"}))
+		e.SetBaseline(&Baseline{})
+		if err := prometheus.NewPedanticRegistry().Register(e); err == nil {
+			t.Errorf("label %q accepted", name)
+		}
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		s := testStats()
+		s.Stats["limit_maxbytes"] = "67108864"
+		return s
+	}))
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+	if limit := e.MemoryLimit(); limit != 0 {
+		t.Errorf("want no limit before the first scrape, got %v", limit)
+	}
+	testutil.CollectAndCount(e)
+	if limit := e.MemoryLimit(); limit != 67108864 {
+		t.Errorf("want limit 67108864, got %v", limit)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+
+	"github.com/grobie/gomemcache/memcache"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/client"
+)
+
+// Features of a memcached server.
+const (
+	FeatureMetaProtocol = "meta_protocol"
+	FeatureTLS          = "tls"
+	FeatureExtstore     = "extstore"
+	FeatureProxy        = "proxy"
+	FeatureSizes        = "sizes"
+	FeatureSegmentedLRU = "segmented_lru"
+)
+
+var (
+	allFeatures = []string{FeatureMetaProtocol, FeatureTLS, FeatureExtstore, FeatureProxy, FeatureSizes, FeatureSegmentedLRU}
+
+	// versionRE matches release versions followed by optional distribution
+	// suffixes, e.g. "1.6.21", "1.6.14-1ubuntu0.1" or "1.4.15 (Red Hat)".
+	versionRE = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?(.*)$`)
+)
+
+// Version is a parsed memcached version string.
+type Version struct {
+	Major, Minor, Patch int
+	// Suffix is anything following the release version, e.g. added by
+	// distributions.
+	Suffix string
+}
+
+// ParseVersion parses the version reported by memcached.
+func ParseVersion(s string) (Version, error) {
+	m := versionRE.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid memcached version %q", s)
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	v.Suffix = m[4]
+	return v, nil
+}
+
+// AtLeast reports whether v is the given release or a later one.
+func (v Version) AtLeast(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch >= patch
+}
+
+// minVersions are the first releases supporting a feature.
+var minVersions = map[string]Version{
+	FeatureMetaProtocol: {Major: 1, Minor: 6},
+	FeatureTLS:          {Major: 1, Minor: 5, Patch: 13},
+	FeatureExtstore:     {Major: 1, Minor: 5, Patch: 4},
+	FeatureProxy:        {Major: 1, Minor: 6, Patch: 13},
+	FeatureSizes:        {Major: 1, Minor: 4, Patch: 31},
+	FeatureSegmentedLRU: {Major: 1, Minor: 4, Patch: 24},
+}
+
+// Features maps the name of a feature to whether the server supports it and
+// has it enabled.
+type Features map[string]bool
+
+// DetectFeatures determines the features of a server. A feature is enabled if
+// the version of the server supports it and its stats and settings show it is
+// active. Versions which can't be parsed are assumed to support all features.
+func DetectFeatures(stats, settings map[string]string) Features {
+	v, err := ParseVersion(stats["version"])
+	supports := func(feature string) bool {
+		first := minVersions[feature]
+		return err != nil || v.AtLeast(first.Major, first.Minor, first.Patch)
+	}
+
+	// extstore and proxy stats are only reported while they are active.
+	_, extstore := stats["extstore_limit_maxbytes"]
+	_, proxy := stats["proxy_backend_total"]
+	active := map[string]bool{
+		FeatureMetaProtocol: true,
+		FeatureTLS:          settings["ssl_enabled"] == "yes",
+		FeatureExtstore:     extstore,
+		FeatureProxy:        proxy,
+		FeatureSizes:        settings["track_sizes"] == "yes",
+		FeatureSegmentedLRU: settings["lru_segmented"] == "yes",
+	}
+	f := Features{}
+	for _, feature := range allFeatures {
+		f[feature] = supports(feature) && active[feature]
+	}
+	return f
+}
+
+// ServerFeatures queries the stats and settings of the server on conn and
+// determines its features.
+func ServerFeatures(conn *client.Conn) (Features, error) {
+	stats, err := conn.Stats()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := conn.Stats("settings")
+	if err != nil {
+		return nil, err
+	}
+	return DetectFeatures(stats, settings), nil
+}
+
+// detectFeatures determines the features of the single server the exporter
+// talks to.
+func detectFeatures(stats map[net.Addr]memcache.Stats, statsSettings map[net.Addr]map[string]string) Features {
+	var settings map[string]string
+	for _, s := range statsSettings {
+		settings = s
+	}
+	for _, t := range stats {
+		return DetectFeatures(t.Stats, settings)
+	}
+	return Features{}
+}
+
+// collectSizes exports the item size histogram of "stats sizes", which the
+// gomemcache client does not support. It uses conn if it is not nil and
+// connects to the server otherwise.
+func (e *Exporter) collectSizes(ch chan<- prometheus.Metric, conn *client.Conn) error {
+	if conn == nil {
+		var err error
+		conn, err = client.Dial(e.address, e.timeout, e.tlsConfig)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+	}
+
+	sizes, err := conn.Stats("sizes")
+	if err != nil {
+		return err
+	}
+	for size, v := range sizes {
+		if size == "sizes_status" {
+			continue
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(e.itemSizes, prometheus.GaugeValue, n, size)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"1.6.21", Version{1, 6, 21, ""}},
+		{"1.6.14-1ubuntu0.1", Version{1, 6, 14, "-1ubuntu0.1"}},
+		{"1.5.6 (Ubuntu)", Version{1, 5, 6, " (Ubuntu)"}},
+		{"1.4", Version{1, 4, 0, ""}},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: want %+v, got %+v", tt.in, tt.want, got)
+		}
+	}
+
+	if _, err := ParseVersion("unknown"); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+
+	v := Version{Major: 1, Minor: 6, Patch: 2}
+	if !v.AtLeast(1, 6, 0) || !v.AtLeast(1, 5, 9) || v.AtLeast(1, 6, 3) || v.AtLeast(2, 0, 0) {
+		t.Errorf("unexpected comparisons for %+v", v)
+	}
+}
+
+func TestDetectFeatures(t *testing.T) {
+	settings := map[string]string{"ssl_enabled": "yes", "track_sizes": "yes", "lru_segmented": "yes"}
+	active := func(version string) map[string]string {
+		return map[string]string{"version": version, "extstore_limit_maxbytes": "1024", "proxy_backend_total": "1"}
+	}
+	for _, tc := range []struct {
+		stats map[string]string
+		want  []string
+	}{
+		{active("1.6.21"), allFeatures},
+		{active("1.6.12"), []string{FeatureMetaProtocol, FeatureTLS, FeatureExtstore, FeatureSizes, FeatureSegmentedLRU}},
+		{active("1.5.12"), []string{FeatureExtstore, FeatureSizes, FeatureSegmentedLRU}},
+		{active("1.4.24"), []string{FeatureSegmentedLRU}},
+		{active("1.4.15 (Red Hat)"), nil},
+		// Unknown versions only depend on the stats and settings.
+		{active("unknown"), allFeatures},
+		{map[string]string{"version": "1.6.21"}, []string{FeatureMetaProtocol, FeatureTLS, FeatureSizes, FeatureSegmentedLRU}},
+	} {
+		f := DetectFeatures(tc.stats, settings)
+		for _, feature := range allFeatures {
+			if want := slices.Contains(tc.want, feature); f[feature] != want {
+				t.Errorf("%v: want %s %v, got %v", tc.stats, feature, want, f[feature])
+			}
+		}
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		s := testStats()
+		s.Stats["version"] = "1.6.21-1+deb12u1"
+		s.Settings["track_sizes"] = "yes"
+		s.Settings["lru_segmented"] = "yes"
+		s.Settings["ssl_enabled"] = "no"
+		s.Sizes = map[string]string{"96": "3", "128": "1"}
+		return s
+	}))
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+
+	want := `
+# HELP memcached_feature_enabled Whether a feature is supported and enabled by the server, detected from its version, stats and settings.
+# TYPE memcached_feature_enabled gauge
+memcached_feature_enabled{feature="extstore"} 0
+memcached_feature_enabled{feature="meta_protocol"} 1
+memcached_feature_enabled{feature="proxy"} 0
+memcached_feature_enabled{feature="segmented_lru"} 1
+memcached_feature_enabled{feature="sizes"} 1
+memcached_feature_enabled{feature="tls"} 0
+# HELP memcached_item_size_items Number of items per 32 byte size bucket, only reported when the server tracks item sizes.
+# TYPE memcached_item_size_items gauge
+memcached_item_size_items{size="128"} 1
+memcached_item_size_items{size="96"} 3
+# HELP memcached_up Could the memcached server be reached.
+# TYPE memcached_up gauge
+memcached_up 1
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want),
+		"memcached_feature_enabled", "memcached_item_size_items", "memcached_up"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/client"
+)
+
+// Operations of the latency histogram.
+const (
+	operationDial          = "dial"
+	operationTLSHandshake  = "tls_handshake"
+	operationStats         = "stats"
+	operationStatsSettings = "stats_settings"
+	operationStatsSizes    = "stats_sizes"
+)
+
+// Results of the latency histogram.
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+// latency measures how long connecting to the server and the requests of a
+// scrape take, whether they succeed or not. Observations carry the target and
+// the ID of the scrape as exemplars, so slow scrapes can be found in the logs.
+type latency struct {
+	durations *prometheus.HistogramVec
+}
+
+func newLatency(constLabels prometheus.Labels) *latency {
+	return &latency{
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   exporterNamespace,
+			Name:        "request_duration_seconds",
+			Help:        "Duration of connecting to the memcached server and of the requests to it during a scrape, by operation and result.",
+			ConstLabels: constLabels,
+			// The classic buckets are exposed to scrapers which don't
+			// support native histograms.
+			Buckets:                         prometheus.ExponentialBuckets(0.0001, 2.5, 12),
+			NativeHistogramBucketFactor:     1.1,
+			NativeHistogramMaxBucketNumber:  100,
+			NativeHistogramMinResetDuration: time.Hour,
+		}, []string{"operation", "result"}),
+	}
+}
+
+// EnableLatencyMetrics measures the duration of connecting to the server and
+// of the requests the exporter makes to it on every scrape. As the gomemcache
+// client hides its connection, every scrape connects to the server once more
+// to measure the dial and TLS handshake, and reuses that connection for
+// "stats sizes".
+func (e *Exporter) EnableLatencyMetrics() {
+	e.latency = newLatency(e.constLabels)
+}
+
+func (l *latency) describe(ch chan<- *prometheus.Desc) {
+	l.durations.Describe(ch)
+}
+
+func (l *latency) collect(ch chan<- prometheus.Metric) {
+	l.durations.Collect(ch)
+}
+
+// timer times the requests of a single scrape. A nil timer only runs the
+// requests.
+type timer struct {
+	latency  *latency
+	id       string
+	exemplar prometheus.Labels
+}
+
+// newTimer returns a timer of a scrape of address with a random ID.
+func (l *latency) newTimer(address string) *timer {
+	id := strconv.FormatUint(rand.Uint64(), 16)
+	exemplar := prometheus.Labels{"scrape_id": id}
+	// Exemplars are limited in size, long socket paths are left out.
+	if utf8.RuneCountInString(address+"target"+"scrape_id"+id) <= prometheus.ExemplarMaxRunes {
+		exemplar["target"] = address
+	}
+	return &timer{latency: l, id: id, exemplar: exemplar}
+}
+
+// logger adds the scrape ID to the logs of the scrape.
+func (t *timer) logger(logger *slog.Logger) *slog.Logger {
+	if t == nil {
+		return logger
+	}
+	return logger.With("scrape_id", t.id)
+}
+
+// time runs request and observes its duration by whether it succeeded.
+func (t *timer) time(operation string, request func() error) error {
+	if t == nil {
+		return request()
+	}
+	start := time.Now()
+	err := request()
+	t.observe(operation, time.Since(start), err)
+	return err
+}
+
+// dial connects to address and observes the durations of the dial and of the
+// TLS handshake. A failed handshake is observed including the dial.
+func (t *timer) dial(address string, timeout time.Duration, tlsConfig *tls.Config) (*client.Conn, error) {
+	start := time.Now()
+	conn, err := client.Dial(address, timeout, tlsConfig)
+	if err != nil {
+		operation := operationDial
+		var opErr *net.OpError
+		if tlsConfig != nil && (!errors.As(err, &opErr) || opErr.Op != "dial") {
+			operation = operationTLSHandshake
+		}
+		t.observe(operation, time.Since(start), err)
+		return nil, err
+	}
+	timings := conn.Timings()
+	t.observe(operationDial, timings.Dial, nil)
+	if tlsConfig != nil {
+		t.observe(operationTLSHandshake, timings.TLSHandshake, nil)
+	}
+	return conn, nil
+}
+
+func (t *timer) observe(operation string, d time.Duration, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+	t.latency.durations.WithLabelValues(operation, result).(prometheus.ExemplarObserver).ObserveWithExemplar(d.Seconds(), t.exemplar)
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestLatencyMetrics(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(testStats))
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	e := NewWithOpts(srv.Addr, time.Second, logger, nil, WithConstLabels(prometheus.Labels{"pool": "sessions"}))
+	e.EnableLatencyMetrics()
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(e)
+
+	for range 2 {
+		if _, err := reg.Gather(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operations := map[string]bool{}
+	for _, f := range families {
+		if f.GetName() != "memcached_exporter_request_duration_seconds" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			operations[labels["operation"]] = true
+			if labels["result"] != resultSuccess {
+				t.Errorf("%s: want result %s, got %s", labels["operation"], resultSuccess, labels["result"])
+			}
+			if labels["pool"] != "sessions" {
+				t.Errorf("constant labels missing: %v", labels)
+			}
+
+			h := m.GetHistogram()
+			if h.GetSampleCount() != 3 {
+				t.Errorf("%s: want 3 observations, got %d", labels["operation"], h.GetSampleCount())
+			}
+			if len(h.GetBucket()) == 0 {
+				t.Errorf("%s: classic buckets missing", labels["operation"])
+			}
+			if h.GetSchema() == 0 && len(h.GetPositiveSpan()) == 0 {
+				t.Errorf("%s: native histogram missing", labels["operation"])
+			}
+			if len(h.GetExemplars()) == 0 {
+				t.Fatalf("%s: exemplars missing", labels["operation"])
+			}
+			exemplar := map[string]string{}
+			for _, l := range h.GetExemplars()[0].GetLabel() {
+				exemplar[l.GetName()] = l.GetValue()
+			}
+			if exemplar["target"] != srv.Addr || exemplar["scrape_id"] == "" {
+				t.Errorf("unexpected exemplar %v", exemplar)
+			}
+			if !strings.Contains(logs.String(), "scrape_id="+exemplar["scrape_id"]) {
+				t.Errorf("scrape_id %s of the exemplar not logged", exemplar["scrape_id"])
+			}
+		}
+	}
+	if len(operations) != 3 || !operations[operationDial] || !operations[operationStats] || !operations[operationStatsSettings] {
+		t.Errorf("unexpected operations %v", operations)
+	}
+}
+
+func TestLatencyMetricsFailure(t *testing.T) {
+	e := New("127.0.0.1:1", time.Second, promslog.NewNopLogger(), nil)
+	e.EnableLatencyMetrics()
+
+	want := map[string]uint64{
+		`{operation="dial",result="error"}`:           1,
+		`{operation="stats",result="error"}`:          1,
+		`{operation="stats_settings",result="error"}`: 1,
+	}
+	ch := make(chan prometheus.Metric, 100)
+	e.Collect(ch)
+	close(ch)
+	got := map[string]uint64{}
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		if pb.GetHistogram() == nil {
+			continue
+		}
+		labels := map[string]string{}
+		for _, l := range pb.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		got[fmt.Sprintf("{operation=%q,result=%q}", labels["operation"], labels["result"])] = pb.GetHistogram().GetSampleCount()
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("want observations %v, got %v", want, got)
+	}
+}
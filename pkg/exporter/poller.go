@@ -0,0 +1,145 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PollOpts configures the background polling mode of an Exporter.
+type PollOpts struct {
+	// Interval between two polls of the memcached server.
+	Interval time.Duration
+	// Jitter is the upper bound of a random delay added to every interval.
+	Jitter time.Duration
+	// MaxAge is the age after which the last successful snapshot is no
+	// longer served. Zero defaults to defaultMaxAgeIntervals intervals.
+	MaxAge time.Duration
+}
+
+// defaultMaxAgeIntervals is the number of poll intervals after which the last
+// successful snapshot is dropped by default.
+const defaultMaxAgeIntervals = 5
+
+type poller struct {
+	e    *Exporter
+	opts PollOpts
+	// now and after are replaced by tests.
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+
+	mu sync.RWMutex
+	// metrics is the last successful snapshot, without memcached_up.
+	metrics     []prometheus.Metric
+	lastSuccess time.Time
+	lastFailed  bool
+	// up is the result of the latest poll, successful or not.
+	up float64
+}
+
+// StartPolling switches the exporter to background polling. The memcached
+// server is queried on its own schedule until ctx is cancelled and Collect
+// serves the last successful snapshot instead of blocking on the server.
+// memcached_up always reflects the latest poll. The first poll completes before StartPolling returns. It must be called
+// before the exporter is registered.
+func (e *Exporter) StartPolling(ctx context.Context, opts PollOpts) {
+	p := newPoller(e, opts)
+	e.poller = p
+	p.poll()
+	go p.run(ctx)
+}
+
+func newPoller(e *Exporter, opts PollOpts) *poller {
+	if opts.MaxAge == 0 {
+		opts.MaxAge = defaultMaxAgeIntervals * opts.Interval
+	}
+	return &poller{e: e, opts: opts, now: time.Now, after: time.After}
+}
+
+func (p *poller) run(ctx context.Context) {
+	for {
+		delay := p.opts.Interval
+		if p.opts.Jitter > 0 {
+			delay += rand.N(p.opts.Jitter)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.after(delay):
+		}
+		p.poll()
+	}
+}
+
+func (p *poller) poll() {
+	ch := make(chan prometheus.Metric)
+	done := make(chan bool, 1)
+	go func() {
+		done <- p.e.collect(ch)
+		close(ch)
+	}()
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		if m.Desc() != p.e.up {
+			metrics = append(metrics, m)
+		}
+	}
+	up := <-done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.up = 0
+	if up {
+		p.up = 1
+	}
+	if !up {
+		p.lastFailed = true
+		p.e.logger.Debug("Poll failed, keeping last snapshot", "address", p.e.address, "last_success", p.lastSuccess)
+		return
+	}
+	p.metrics = metrics
+	p.lastSuccess = p.now()
+	p.lastFailed = false
+}
+
+func (p *poller) collect(ch chan<- prometheus.Metric) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(p.e.up, prometheus.GaugeValue, p.up)
+	if p.lastSuccess.IsZero() {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(p.e.lastScrapeTimestamp, prometheus.GaugeValue, float64(p.lastSuccess.UnixNano())/1e9)
+	if p.opts.MaxAge > 0 && p.now().Sub(p.lastSuccess) > p.opts.MaxAge {
+		ch <- prometheus.MustNewConstMetric(p.e.lastScrapeStale, prometheus.GaugeValue, 1)
+		return
+	}
+
+	stale := 0.
+	if p.lastFailed {
+		stale = 1
+	}
+	ch <- prometheus.MustNewConstMetric(p.e.lastScrapeStale, prometheus.GaugeValue, stale)
+	for _, m := range p.metrics {
+		ch <- m
+	}
+}
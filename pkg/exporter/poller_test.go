@@ -0,0 +1,161 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func testStats() memcachedtest.Stats {
+	return memcachedtest.Stats{
+		Stats: map[string]string{
+			"version":       "1.6.21",
+			"uptime":        "100",
+			"time":          "1700000000",
+			"cmd_set":       "3",
+			"cas_hits":      "1",
+			"cas_misses":    "0",
+			"cas_badval":    "0",
+			"curr_items":    "2",
+			"rusage_user":   "0.5",
+			"rusage_system": "0.25",
+		},
+		Settings: map[string]string{"maxconns": "1024"},
+	}
+}
+
+func TestPolling(t *testing.T) {
+	var failing atomic.Bool
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		s := testStats()
+		if failing.Load() {
+			s.Stats["curr_items"] = "broken"
+		}
+		return s
+	}))
+
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+	now := time.Unix(1700000000, 0)
+	p := newPoller(e, PollOpts{Interval: time.Minute, MaxAge: 5 * time.Minute})
+	p.now = func() time.Time { return now }
+	e.poller = p
+
+	want := `
+# HELP memcached_up Could the memcached server be reached.
+# TYPE memcached_up gauge
+memcached_up 0
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), "memcached_up", "memcached_current_items"); err != nil {
+		t.Fatalf("before the first poll: %v", err)
+	}
+
+	p.poll()
+	want = `
+# HELP memcached_current_items Current number of items stored by this instance.
+# TYPE memcached_current_items gauge
+memcached_current_items 2
+# HELP memcached_exporter_last_scrape_stale Whether the served metrics come from an older snapshot because the last background poll failed.
+# TYPE memcached_exporter_last_scrape_stale gauge
+memcached_exporter_last_scrape_stale 0
+# HELP memcached_exporter_last_scrape_timestamp_seconds Unix time of the last successful background poll of the memcached server.
+# TYPE memcached_exporter_last_scrape_timestamp_seconds gauge
+memcached_exporter_last_scrape_timestamp_seconds 1.7e+09
+# HELP memcached_up Could the memcached server be reached.
+# TYPE memcached_up gauge
+memcached_up 1
+`
+	metrics := []string{"memcached_up", "memcached_current_items", "memcached_exporter_last_scrape_stale", "memcached_exporter_last_scrape_timestamp_seconds"}
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), metrics...); err != nil {
+		t.Fatalf("after a successful poll: %v", err)
+	}
+
+	failing.Store(true)
+	now = now.Add(time.Minute)
+	p.poll()
+	// The snapshot is kept, but memcached_up reports the failed poll.
+	want = strings.Replace(want, "memcached_exporter_last_scrape_stale 0", "memcached_exporter_last_scrape_stale 1", 1)
+	want = strings.Replace(want, "memcached_up 1", "memcached_up 0", 1)
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), metrics...); err != nil {
+		t.Fatalf("after a failed poll: %v", err)
+	}
+
+	now = now.Add(5 * time.Minute)
+	want = `
+# HELP memcached_up Could the memcached server be reached.
+# TYPE memcached_up gauge
+memcached_up 0
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), "memcached_up", "memcached_current_items"); err != nil {
+		t.Fatalf("after max age: %v", err)
+	}
+
+	failing.Store(false)
+	p.poll()
+	want = `
+# HELP memcached_up Could the memcached server be reached.
+# TYPE memcached_up gauge
+memcached_up 1
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), "memcached_up"); err != nil {
+		t.Fatalf("after recovery: %v", err)
+	}
+
+	if got := newPoller(e, PollOpts{Interval: time.Minute}).opts.MaxAge; got != 5*time.Minute {
+		t.Errorf("want a default max age of 5m, got %s", got)
+	}
+}
+
+func TestPollingSchedule(t *testing.T) {
+	var polls atomic.Int64
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		polls.Add(1)
+		return testStats()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+	p := newPoller(e, PollOpts{Interval: time.Minute})
+	ticks := make(chan time.Time)
+	p.after = func(d time.Duration) <-chan time.Time {
+		if d != time.Minute {
+			t.Errorf("want interval of 1m, got %s", d)
+		}
+		return ticks
+	}
+	done := make(chan struct{})
+	go func() {
+		p.run(ctx)
+		close(done)
+	}()
+
+	// The unbuffered channel only accepts a tick once the previous poll has
+	// finished and the poller waits again.
+	for range 3 {
+		ticks <- time.Time{}
+	}
+	cancel()
+	<-done
+	if n := polls.Load(); n < 3 {
+		t.Errorf("want a poll on every tick, got %d stats commands", n)
+	}
+}
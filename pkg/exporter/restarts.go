@@ -0,0 +1,139 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// resetCounters are cleared by "stats reset" but never decrease otherwise.
+var resetCounters = []string{"cmd_get", "cmd_set", "get_hits", "get_misses", "total_items", "bytes_read", "bytes_written"}
+
+// restarts detects server restarts and "stats reset" by comparing the stats
+// of consecutive scrapes.
+type restarts struct {
+	mu              sync.Mutex
+	seen            bool
+	pid             string
+	uptime          float64
+	counters        map[string]float64
+	started         float64
+	restarts        float64
+	resets          float64
+	lastReset       float64
+	restartsDesc    *prometheus.Desc
+	lastRestartDesc *prometheus.Desc
+	resetsDesc      *prometheus.Desc
+	lastResetDesc   *prometheus.Desc
+}
+
+func newRestarts(constLabels prometheus.Labels) *restarts {
+	return &restarts{
+		restartsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "restarts_total"),
+			"Number of server restarts detected by the exporter from a changed pid or decreasing uptime.",
+			nil,
+			constLabels,
+		),
+		lastRestartDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "last_restart_timestamp_seconds"),
+			"Unix time the server was last started according to its clock.",
+			nil,
+			constLabels,
+		),
+		resetsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "stats_resets_total"),
+			"Number of stats resets detected by the exporter from counters decreasing without a restart.",
+			nil,
+			constLabels,
+		),
+		lastResetDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "last_stats_reset_timestamp_seconds"),
+			"Unix time according to the server clock of the first scrape after the last detected stats reset.",
+			nil,
+			constLabels,
+		),
+	}
+}
+
+func (r *restarts) describe(ch chan<- *prometheus.Desc) {
+	ch <- r.restartsDesc
+	ch <- r.lastRestartDesc
+	ch <- r.resetsDesc
+	ch <- r.lastResetDesc
+}
+
+// update compares the stats of a scrape with the previous one.
+func (r *restarts) update(stats map[string]string) {
+	uptime, err := strconv.ParseFloat(stats["uptime"], 64)
+	if err != nil {
+		return
+	}
+	now, err := strconv.ParseFloat(stats["time"], 64)
+	if err != nil {
+		return
+	}
+	counters := map[string]float64{}
+	for _, k := range resetCounters {
+		if v, err := strconv.ParseFloat(stats[k], 64); err == nil {
+			counters[k] = v
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen {
+		switch {
+		case stats["pid"] != r.pid || uptime < r.uptime:
+			r.restarts++
+		case decreased(r.counters, counters):
+			r.resets++
+			r.lastReset = now
+		}
+	}
+	r.seen = true
+	r.pid = stats["pid"]
+	r.uptime = uptime
+	r.counters = counters
+	r.started = now - uptime
+}
+
+// decreased reports whether any counter went backwards.
+func decreased(before, after map[string]float64) bool {
+	for k, v := range after {
+		if prev, ok := before[k]; ok && v < prev {
+			return true
+		}
+	}
+	return false
+}
+
+// collect sends nothing until a scrape succeeded, so unreachable targets
+// don't get counters which were never compared against a baseline.
+func (r *restarts) collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.seen {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(r.restartsDesc, prometheus.CounterValue, r.restarts)
+	ch <- prometheus.MustNewConstMetric(r.resetsDesc, prometheus.CounterValue, r.resets)
+	ch <- prometheus.MustNewConstMetric(r.lastRestartDesc, prometheus.GaugeValue, r.started)
+	if r.resets > 0 {
+		ch <- prometheus.MustNewConstMetric(r.lastResetDesc, prometheus.GaugeValue, r.lastReset)
+	}
+}
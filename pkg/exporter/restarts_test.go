@@ -0,0 +1,94 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestRestarts(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		stats = map[string]string{}
+	)
+	set := func(kv ...string) {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := 0; i < len(kv); i += 2 {
+			stats[kv[i]] = kv[i+1]
+		}
+	}
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		mu.Lock()
+		defer mu.Unlock()
+		s := testStats()
+		for k, v := range stats {
+			s.Stats[k] = v
+		}
+		return s
+	}))
+	e := New(srv.Addr, time.Second, promslog.NewNopLogger(), nil)
+
+	metrics := []string{"memcached_restarts_total", "memcached_last_restart_timestamp_seconds", "memcached_stats_resets_total", "memcached_last_stats_reset_timestamp_seconds"}
+	down := New("127.0.0.1:1", 100*time.Millisecond, promslog.NewNopLogger(), nil)
+	if n := testutil.CollectAndCount(down, metrics...); n != 0 {
+		t.Errorf("want no restart metrics before a successful scrape, got %d", n)
+	}
+
+	expect := func(restarts, started, resets, lastReset string) {
+		t.Helper()
+		want := `
+# HELP memcached_last_restart_timestamp_seconds Unix time the server was last started according to its clock.
+# TYPE memcached_last_restart_timestamp_seconds gauge
+memcached_last_restart_timestamp_seconds ` + started + `
+# HELP memcached_restarts_total Number of server restarts detected by the exporter from a changed pid or decreasing uptime.
+# TYPE memcached_restarts_total counter
+memcached_restarts_total ` + restarts + `
+# HELP memcached_stats_resets_total Number of stats resets detected by the exporter from counters decreasing without a restart.
+# TYPE memcached_stats_resets_total counter
+memcached_stats_resets_total ` + resets + `
+`
+		if lastReset != "" {
+			want += `# HELP memcached_last_stats_reset_timestamp_seconds Unix time according to the server clock of the first scrape after the last detected stats reset.
+# TYPE memcached_last_stats_reset_timestamp_seconds gauge
+memcached_last_stats_reset_timestamp_seconds ` + lastReset + `
+`
+		}
+		if err := testutil.CollectAndCompare(e, strings.NewReader(want), metrics...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set("pid", "100", "uptime", "100", "time", "1700000000", "cmd_get", "50")
+	expect("0", "1.6999999e+09", "0", "")
+
+	// Counters going backwards without a restart are a stats reset.
+	set("uptime", "160", "time", "1700000060", "cmd_get", "2")
+	expect("0", "1.6999999e+09", "1", "1.70000006e+09")
+
+	// A new pid is a restart, even though uptime grew.
+	set("pid", "200", "uptime", "200", "time", "1700000100", "cmd_get", "1")
+	expect("1", "1.6999999e+09", "1", "1.70000006e+09")
+
+	set("uptime", "5", "time", "1700000200")
+	expect("2", "1.700000195e+09", "1", "1.70000006e+09")
+}
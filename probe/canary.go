@@ -0,0 +1,159 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package probe implements synthetic probes which exercise a memcached server
+// with real requests.
+package probe
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/grobie/gomemcache/memcache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	subsystemCanary = "canary"
+
+	// DefaultCanaryKeyPrefix is the key namespace used by the canary probe.
+	DefaultCanaryKeyPrefix = "memcached_exporter_canary:"
+
+	// canaryTTL bounds the lifetime of keys left behind by failed probes.
+	canaryTTL = 60
+)
+
+var (
+	canaryOperations = []string{"set", "get", "cas", "delete"}
+
+	errValueMismatch = errors.New("returned value does not match the stored value")
+)
+
+// Canary performs set, get, CAS and delete operations against a memcached
+// server on every scrape. It implements prometheus.Collector.
+type Canary struct {
+	address   string
+	keyPrefix string
+	timeout   time.Duration
+	logger    *slog.Logger
+	tlsConfig *tls.Config
+
+	metrics *opMetrics
+}
+
+// NewCanary returns an initialized canary probe. Keys are created below
+// keyPrefix with a random suffix, so several exporters can probe the same
+// server. The optional constLabels are added to every metric.
+func NewCanary(address, keyPrefix string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config, constLabels prometheus.Labels) *Canary {
+	return &Canary{
+		address:   address,
+		keyPrefix: keyPrefix,
+		timeout:   timeout,
+		logger:    logger,
+		tlsConfig: tlsConfig,
+		metrics:   newOpMetrics(subsystemCanary, "operation", "canary operation", canaryOperations, constLabels),
+	}
+}
+
+// Describe describes all the metrics exported by the canary probe. It
+// implements prometheus.Collector.
+func (c *Canary) Describe(ch chan<- *prometheus.Desc) {
+	c.metrics.describe(ch)
+}
+
+// Collect runs the canary operations and delivers their results as
+// Prometheus metrics. It implements prometheus.Collector.
+func (c *Canary) Collect(ch chan<- prometheus.Metric) {
+	c.metrics.collect(ch, c.probe())
+}
+
+// probe runs all operations in order and returns the error of each one.
+// Operations following a failed one are not attempted and fail as well.
+func (c *Canary) probe() map[string]error {
+	results := map[string]error{}
+	fail := func(from int, err error) map[string]error {
+		for _, op := range canaryOperations[from:] {
+			results[op] = err
+		}
+		return results
+	}
+
+	client, err := memcache.New(c.address)
+	if err != nil {
+		c.logger.Error("Failed to connect to memcached", "err", err)
+		return fail(0, err)
+	}
+	client.Timeout = c.timeout
+	client.TlsConfig = c.tlsConfig
+
+	key, err := randomString(8)
+	if err != nil {
+		return fail(0, err)
+	}
+	key = c.keyPrefix + key
+	value, err := randomString(16)
+	if err != nil {
+		return fail(0, err)
+	}
+
+	var item *memcache.Item
+	steps := []func() error{
+		func() error {
+			return client.Set(&memcache.Item{Key: key, Value: []byte(value), Expiration: canaryTTL})
+		},
+		func() error {
+			got, err := client.Get(key)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(got.Value, []byte(value)) {
+				return errValueMismatch
+			}
+			item = got
+			return nil
+		},
+		func() error {
+			item.Value = []byte(value + value)
+			if err := client.CompareAndSwap(item); err != nil {
+				return err
+			}
+			got, err := client.Get(key)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(got.Value, item.Value) {
+				return errValueMismatch
+			}
+			return nil
+		},
+		func() error {
+			return client.Delete(key)
+		},
+	}
+
+	for i, step := range steps {
+		op := canaryOperations[i]
+		start := time.Now()
+		if err := step(); err != nil {
+			c.logger.Error("Canary operation failed", "operation", op, "key", key, "err", err)
+			return fail(i, fmt.Errorf("%s: %w", op, err))
+		}
+		c.metrics.observe(op, time.Since(start))
+		results[op] = nil
+	}
+	return results
+}
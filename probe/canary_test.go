@@ -0,0 +1,120 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func unknownCommand(w *bufio.Writer, _ *bufio.Reader, _ string) bool {
+	w.WriteString("ERROR\r\n")
+	return true
+}
+
+func TestCanary(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		cache := memcachedtest.NewCache()
+		srv := memcachedtest.NewServer(t, cache.Handler(unknownCommand))
+		c := NewCanary(srv.Addr, DefaultCanaryKeyPrefix, time.Second, promslog.NewNopLogger(), nil, nil)
+
+		want := `
+# HELP memcached_canary_success Whether the last canary operation succeeded.
+# TYPE memcached_canary_success gauge
+memcached_canary_success{operation="cas"} 1
+memcached_canary_success{operation="delete"} 1
+memcached_canary_success{operation="get"} 1
+memcached_canary_success{operation="set"} 1
+`
+		if err := testutil.CollectAndCompare(c, strings.NewReader(want), "memcached_canary_success"); err != nil {
+			t.Fatal(err)
+		}
+		if n := testutil.CollectAndCount(c, "memcached_canary_duration_seconds"); n != 4 {
+			t.Errorf("want 4 latency histograms, got %d", n)
+		}
+		if n := cache.Len(); n != 0 {
+			t.Errorf("canary left %d keys behind", n)
+		}
+	})
+
+	t.Run("Corrupted value", func(t *testing.T) {
+		t.Parallel()
+
+		cache := memcachedtest.NewCache()
+		srv := memcachedtest.NewServer(t, func(w *bufio.Writer, r *bufio.Reader, line string) bool {
+			if strings.HasPrefix(line, "gets ") {
+				key := strings.Fields(line)[1]
+				w.WriteString("VALUE " + key + " 0 3 1\r\nbad\r\nEND\r\n")
+				return true
+			}
+			return cache.Handler(unknownCommand)(w, r, line)
+		})
+		c := NewCanary(srv.Addr, DefaultCanaryKeyPrefix, time.Second, promslog.NewNopLogger(), nil, nil)
+
+		want := `
+# HELP memcached_canary_failures_total Total number of failed canary operations.
+# TYPE memcached_canary_failures_total counter
+memcached_canary_failures_total{operation="cas"} 1
+memcached_canary_failures_total{operation="delete"} 1
+memcached_canary_failures_total{operation="get"} 1
+# HELP memcached_canary_success Whether the last canary operation succeeded.
+# TYPE memcached_canary_success gauge
+memcached_canary_success{operation="cas"} 0
+memcached_canary_success{operation="delete"} 0
+memcached_canary_success{operation="get"} 0
+memcached_canary_success{operation="set"} 1
+`
+		if err := testutil.CollectAndCompare(c, strings.NewReader(want), "memcached_canary_success", "memcached_canary_failures_total"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewCanary("127.0.0.1:1", DefaultCanaryKeyPrefix, 100*time.Millisecond, promslog.NewNopLogger(), nil, nil)
+		if n := testutil.CollectAndCount(c, "memcached_canary_duration_seconds"); n != 0 {
+			t.Errorf("want no latency observations, got %d", n)
+		}
+	})
+	t.Run("Const labels", func(t *testing.T) {
+		t.Parallel()
+
+		cache := memcachedtest.NewCache()
+		srv := memcachedtest.NewServer(t, cache.Handler(unknownCommand))
+		c := NewCanary(srv.Addr, DefaultCanaryKeyPrefix, time.Second, promslog.NewNopLogger(), nil, prometheus.Labels{"pool": "sessions"})
+
+		want := `
+# HELP memcached_canary_success Whether the last canary operation succeeded.
+# TYPE memcached_canary_success gauge
+memcached_canary_success{operation="cas",pool="sessions"} 1
+memcached_canary_success{operation="delete",pool="sessions"} 1
+memcached_canary_success{operation="get",pool="sessions"} 1
+memcached_canary_success{operation="set",pool="sessions"} 1
+`
+		if err := testutil.CollectAndCompare(c, strings.NewReader(want), "memcached_canary_success"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/client"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+const subsystemMeta = "meta"
+
+var metaCommands = []string{"ms", "mg", "md", "mn"}
+
+// metaStep is a single meta protocol request. Several steps can exercise the
+// same command with different expected response codes.
+type metaStep struct {
+	command string
+	run     func(c *client.Conn) error
+}
+
+// Meta exercises the meta protocol commands ms, mg, md and mn against a
+// memcached server on every scrape and validates their responses. It
+// implements prometheus.Collector.
+type Meta struct {
+	address   string
+	keyPrefix string
+	timeout   time.Duration
+	logger    *slog.Logger
+	tlsConfig *tls.Config
+
+	metrics *opMetrics
+}
+
+// NewMeta returns an initialized meta protocol probe. Keys are created below
+// keyPrefix with a random suffix, so several exporters can probe the same
+// server. The optional constLabels are added to every metric.
+func NewMeta(address, keyPrefix string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config, constLabels prometheus.Labels) *Meta {
+	return &Meta{
+		address:   address,
+		keyPrefix: keyPrefix,
+		timeout:   timeout,
+		logger:    logger,
+		tlsConfig: tlsConfig,
+		metrics:   newOpMetrics(subsystemMeta, "command", "meta protocol command", metaCommands, constLabels),
+	}
+}
+
+// Describe describes all the metrics exported by the meta protocol probe. It
+// implements prometheus.Collector.
+func (m *Meta) Describe(ch chan<- *prometheus.Desc) {
+	m.metrics.describe(ch)
+}
+
+// Collect runs the meta protocol commands and delivers their results as
+// Prometheus metrics. It implements prometheus.Collector.
+func (m *Meta) Collect(ch chan<- prometheus.Metric) {
+	m.metrics.collect(ch, m.probe())
+}
+
+func (m *Meta) probe() map[string]error {
+	results := map[string]error{}
+
+	conn, err := client.Dial(m.address, m.timeout, m.tlsConfig)
+	if err != nil {
+		m.logger.Error("Failed to connect to memcached", "err", err)
+		return results
+	}
+	defer conn.Close()
+
+	// Servers whose features can't be detected are probed anyway.
+	if features, err := exporter.ServerFeatures(conn); err == nil && !features[exporter.FeatureMetaProtocol] {
+		m.logger.Warn("Server does not support the meta protocol")
+		return results
+	}
+
+	key, err := randomString(8)
+	if err != nil {
+		return results
+	}
+	key = m.keyPrefix + key
+	value, err := randomString(16)
+	if err != nil {
+		return results
+	}
+	flags := rand.Uint32()
+
+	steps := []metaStep{
+		{"ms", func(c *client.Conn) error {
+			return metaStore(c, fmt.Sprintf("ms %s %d T%d F%d", key, len(value), canaryTTL, flags), value, "HD")
+		}},
+		// Adding an existing key must not store it.
+		{"ms", func(c *client.Conn) error {
+			return metaStore(c, fmt.Sprintf("ms %s %d T%d ME", key, len(value), canaryTTL), value, "NS")
+		}},
+		{"mg", func(c *client.Conn) error {
+			return metaGet(c, key, value, flags)
+		}},
+		{"md", func(c *client.Conn) error {
+			return expectResponse(c, "md "+key, "HD")
+		}},
+		// The deleted key must be gone.
+		{"mg", func(c *client.Conn) error {
+			return expectResponse(c, "mg "+key+" v", "EN")
+		}},
+		{"mn", func(c *client.Conn) error {
+			return expectResponse(c, "mn", "MN")
+		}},
+	}
+
+	for i, step := range steps {
+		start := time.Now()
+		if err := step.run(conn); err != nil {
+			m.logger.Error("Meta protocol command failed", "command", step.command, "key", key, "err", err)
+			for _, s := range steps[i:] {
+				results[s.command] = err
+			}
+			return results
+		}
+		m.metrics.observe(step.command, time.Since(start))
+		if _, ok := results[step.command]; !ok {
+			results[step.command] = nil
+		}
+	}
+	return results
+}
+
+func expectResponse(c *client.Conn, line, code string) error {
+	resp, err := c.Command(line)
+	if err != nil {
+		return err
+	}
+	if resp != code {
+		return fmt.Errorf("unexpected response %q to %q, want %s", resp, line, code)
+	}
+	return nil
+}
+
+func metaStore(c *client.Conn, line, value, code string) error {
+	if err := c.Send(line, []byte(value)); err != nil {
+		return err
+	}
+	resp, err := c.ReadLine()
+	if err != nil {
+		return err
+	}
+	if resp != code {
+		return fmt.Errorf("unexpected response %q to %q, want %s", resp, line, code)
+	}
+	return nil
+}
+
+// metaGet fetches key with its value, TTL, CAS and client flags and verifies
+// them against what was stored.
+func metaGet(c *client.Conn, key, value string, flags uint32) error {
+	line := "mg " + key + " v t c f"
+	resp, err := c.Command(line)
+	if err != nil {
+		return err
+	}
+	f := strings.Fields(resp)
+	if len(f) < 2 || f[0] != "VA" {
+		return fmt.Errorf("unexpected response %q to %q, want VA", resp, line)
+	}
+	size, err := strconv.Atoi(f[1])
+	if err != nil {
+		return fmt.Errorf("invalid value size in %q: %w", resp, err)
+	}
+	data, err := c.ReadData(size)
+	if err != nil {
+		return err
+	}
+	if string(data) != value {
+		return errValueMismatch
+	}
+
+	returned := map[byte]string{}
+	for _, flag := range f[2:] {
+		returned[flag[0]] = flag[1:]
+	}
+	if got := returned['f']; got != strconv.FormatUint(uint64(flags), 10) {
+		return fmt.Errorf("returned client flags %q, want %d", got, flags)
+	}
+	if ttl, err := strconv.Atoi(returned['t']); err != nil || ttl <= 0 || ttl > canaryTTL {
+		return fmt.Errorf("returned TTL %q, want 1-%d", returned['t'], canaryTTL)
+	}
+	if cas, err := strconv.ParseUint(returned['c'], 10, 64); err != nil || cas == 0 {
+		return fmt.Errorf("returned invalid CAS value %q", returned['c'])
+	}
+	return nil
+}
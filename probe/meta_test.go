@@ -0,0 +1,128 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func TestMeta(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		cache := memcachedtest.NewCache()
+		srv := memcachedtest.NewServer(t, cache.Handler(unknownCommand))
+		m := NewMeta(srv.Addr, DefaultCanaryKeyPrefix, time.Second, promslog.NewNopLogger(), nil, nil)
+
+		want := `
+# HELP memcached_meta_success Whether the last meta protocol command succeeded.
+# TYPE memcached_meta_success gauge
+memcached_meta_success{command="md"} 1
+memcached_meta_success{command="mg"} 1
+memcached_meta_success{command="mn"} 1
+memcached_meta_success{command="ms"} 1
+`
+		if err := testutil.CollectAndCompare(m, strings.NewReader(want), "memcached_meta_success"); err != nil {
+			t.Fatal(err)
+		}
+		if n := cache.Len(); n != 0 {
+			t.Errorf("meta probe left %d keys behind", n)
+		}
+	})
+
+	t.Run("Delete not applied", func(t *testing.T) {
+		t.Parallel()
+
+		cache := memcachedtest.NewCache()
+		srv := memcachedtest.NewServer(t, func(w *bufio.Writer, r *bufio.Reader, line string) bool {
+			if strings.HasPrefix(line, "md ") {
+				w.WriteString("HD\r\n")
+				return true
+			}
+			return cache.Handler(unknownCommand)(w, r, line)
+		})
+		m := NewMeta(srv.Addr, DefaultCanaryKeyPrefix, time.Second, promslog.NewNopLogger(), nil, nil)
+
+		want := `
+# HELP memcached_meta_success Whether the last meta protocol command succeeded.
+# TYPE memcached_meta_success gauge
+memcached_meta_success{command="md"} 1
+memcached_meta_success{command="mg"} 0
+memcached_meta_success{command="mn"} 0
+memcached_meta_success{command="ms"} 1
+`
+		if err := testutil.CollectAndCompare(m, strings.NewReader(want), "memcached_meta_success"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		srv := memcachedtest.NewServer(t, unknownCommand)
+		m := NewMeta(srv.Addr, DefaultCanaryKeyPrefix, time.Second, promslog.NewNopLogger(), nil, nil)
+
+		want := `
+# HELP memcached_meta_failures_total Total number of failed meta protocol commands.
+# TYPE memcached_meta_failures_total counter
+memcached_meta_failures_total{command="md"} 1
+memcached_meta_failures_total{command="mg"} 1
+memcached_meta_failures_total{command="mn"} 1
+memcached_meta_failures_total{command="ms"} 1
+`
+		if err := testutil.CollectAndCompare(m, strings.NewReader(want), "memcached_meta_failures_total"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("Old version", func(t *testing.T) {
+		t.Parallel()
+
+		cache := memcachedtest.NewCache()
+		srv := memcachedtest.NewServer(t, func(w *bufio.Writer, r *bufio.Reader, line string) bool {
+			switch line {
+			case "stats":
+				memcachedtest.WriteStats(w, map[string]string{"version": "1.5.22"})
+				return true
+			case "stats settings":
+				memcachedtest.WriteStats(w, nil)
+				return true
+			}
+			return cache.Handler(unknownCommand)(w, r, line)
+		})
+		m := NewMeta(srv.Addr, DefaultCanaryKeyPrefix, time.Second, promslog.NewNopLogger(), nil, nil)
+
+		want := `
+# HELP memcached_meta_success Whether the last meta protocol command succeeded.
+# TYPE memcached_meta_success gauge
+memcached_meta_success{command="md"} 0
+memcached_meta_success{command="mg"} 0
+memcached_meta_success{command="mn"} 0
+memcached_meta_success{command="ms"} 0
+`
+		if err := testutil.CollectAndCompare(m, strings.NewReader(want), "memcached_meta_success"); err != nil {
+			t.Fatal(err)
+		}
+		if n := cache.Len(); n != 0 {
+			t.Errorf("meta probe stored %d keys on an old server", n)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+// latencyBuckets cover 100µs to ~1.6s.
+var latencyBuckets = prometheus.ExponentialBuckets(0.0001, 2, 15)
+
+// opMetrics are the success, latency and failure metrics of a probe, broken
+// down by operation.
+type opMetrics struct {
+	ops      []string
+	success  *prometheus.Desc
+	duration *prometheus.HistogramVec
+	failures *prometheus.CounterVec
+}
+
+func newOpMetrics(subsystem, label, noun string, ops []string, constLabels prometheus.Labels) *opMetrics {
+	return &opMetrics{
+		ops: ops,
+		success: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "success"),
+			"Whether the last "+noun+" succeeded.",
+			[]string{label},
+			constLabels,
+		),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystem,
+			Name:        "duration_seconds",
+			Help:        "Duration of successful " + noun + "s.",
+			Buckets:     latencyBuckets,
+			ConstLabels: constLabels,
+		}, []string{label}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystem,
+			Name:        "failures_total",
+			Help:        "Total number of failed " + noun + "s.",
+			ConstLabels: constLabels,
+		}, []string{label}),
+	}
+}
+
+func (m *opMetrics) describe(ch chan<- *prometheus.Desc) {
+	ch <- m.success
+	m.duration.Describe(ch)
+	m.failures.Describe(ch)
+}
+
+func (m *opMetrics) observe(op string, d time.Duration) {
+	m.duration.WithLabelValues(op).Observe(d.Seconds())
+}
+
+// collect delivers the metrics for the given results. Operations without a
+// result, or with a non-nil error, are failures.
+func (m *opMetrics) collect(ch chan<- prometheus.Metric, results map[string]error) {
+	for _, op := range m.ops {
+		v := 0.
+		if err, ok := results[op]; ok && err == nil {
+			v = 1
+		} else {
+			m.failures.WithLabelValues(op).Inc()
+		}
+		ch <- prometheus.MustNewConstMetric(m.success, prometheus.GaugeValue, v, op)
+	}
+	m.duration.Collect(ch)
+	m.failures.Collect(ch)
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
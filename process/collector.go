@@ -0,0 +1,135 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package process
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// NewServerCollector returns a collector of the metrics of a single server,
+// whose process is returned by pidFn.
+type NewServerCollector func(server string, pidFn func() (int, error)) prometheus.Collector
+
+// Collector exports the standard process metrics of every process found,
+// labeled by server. Processes are looked up again on every scrape, so
+// restarted servers are followed.
+type Collector struct {
+	finder        *Finder
+	namespace     string
+	logger        *slog.Logger
+	newCollectors []NewServerCollector
+
+	mu      sync.Mutex
+	servers map[string]*server
+}
+
+type server struct {
+	// pid is only read while collecting, under the lock of the Collector.
+	pid       int
+	collector prometheus.Collector
+}
+
+// NewCollector returns a Collector of the processes found by finder, whose
+// metrics are prefixed with namespace. The collectors returned by
+// newCollectors, like the OSCollector, are added for every server.
+func NewCollector(finder *Finder, namespace string, logger *slog.Logger, newCollectors ...NewServerCollector) *Collector {
+	return &Collector{
+		finder:        finder,
+		namespace:     namespace,
+		logger:        logger,
+		newCollectors: newCollectors,
+		servers:       map[string]*server{},
+	}
+}
+
+// Describe implements prometheus.Collector. The collector is unchecked, as
+// the servers are only known at collection time.
+func (c *Collector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	processes, err := c.finder.Find()
+	if err != nil {
+		c.logger.Error("Failed to find memcached processes", "err", err)
+		return
+	}
+	if len(processes) == 0 {
+		c.logger.Warn("No memcached process found")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	found := map[string]bool{}
+	for _, p := range processes {
+		found[p.Server] = true
+		s, ok := c.servers[p.Server]
+		if !ok {
+			s = &server{}
+			s.collector = c.newServer(p.Server, func() (int, error) { return s.pid, nil })
+			c.servers[p.Server] = s
+		}
+		s.pid = p.PID
+		s.collector.Collect(ch)
+	}
+	for name := range c.servers {
+		if !found[name] {
+			delete(c.servers, name)
+		}
+	}
+}
+
+// CheckConstLabels returns an error if labels can't be added to the metrics
+// of the collector, because they are invalid or clash with a variable label
+// of a server's collectors, including the server label itself.
+func (c *Collector) CheckConstLabels(labels prometheus.Labels) error {
+	registry := prometheus.WrapRegistererWith(labels, prometheus.NewPedanticRegistry())
+	return registry.Register(c.newServer("", func() (int, error) { return 0, nil }))
+}
+
+// newServer returns the collectors of server, whose process is returned by
+// pidFn.
+func (c *Collector) newServer(server string, pidFn func() (int, error)) prometheus.Collector {
+	cs := []prometheus.Collector{
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{
+			PidFn:     pidFn,
+			Namespace: c.namespace,
+		}),
+	}
+	for _, newCollector := range c.newCollectors {
+		cs = append(cs, newCollector(server, pidFn))
+	}
+	return prometheus.WrapCollectorWith(prometheus.Labels{"server": server}, multiCollector(cs))
+}
+
+// multiCollector combines the collectors of a server.
+type multiCollector []prometheus.Collector
+
+// Describe implements prometheus.Collector.
+func (m multiCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m multiCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m {
+		c.Collect(ch)
+	}
+}
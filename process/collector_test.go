@@ -0,0 +1,97 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package process
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+)
+
+func TestCollector(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process metrics are only collected on Linux")
+	}
+
+	// The fake procfs points at the test process, whose metrics are read
+	// from the real procfs by the process collector.
+	pid := strconv.Itoa(os.Getpid())
+	root := writeProc(t, map[string]string{pid: "memcached"}, map[string][]string{pid: {"socket:[1001]"}})
+	perServer := func(server string, pidFn func() (int, error)) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "memcached_test_pid", Help: "PID of " + server + "."}, func() float64 {
+			pid, _ := pidFn()
+			return float64(pid)
+		})
+	}
+	c := NewCollector(NewFinder(Opts{Listen: []string{"11211"}, Root: root}), "memcached", promslog.NewNopLogger(), perServer)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, f := range families {
+		if f.GetName() != "memcached_process_start_time_seconds" {
+			continue
+		}
+		found = true
+		if l := f.GetMetric()[0].GetLabel(); len(l) != 1 || l[0].GetName() != "server" || l[0].GetValue() != "11211" {
+			t.Errorf("unexpected labels %v", l)
+		}
+	}
+	if !found {
+		t.Fatal("process metrics missing")
+	}
+	want := `
+# HELP memcached_test_pid PID of 11211.
+# TYPE memcached_test_pid gauge
+memcached_test_pid{server="11211"} ` + pid + `
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "memcached_test_pid"); err != nil {
+		t.Error(err)
+	}
+
+	// Servers which are gone are dropped.
+	if err := os.RemoveAll(root + "/" + pid); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(c, "memcached_process_start_time_seconds"); n != 0 {
+		t.Errorf("want no metrics, got %d", n)
+	}
+	if len(c.servers) != 0 {
+		t.Errorf("want no servers, got %v", c.servers)
+	}
+}
+
+func TestCollectorCheckConstLabels(t *testing.T) {
+	c := NewCollector(NewFinder(Opts{}), "memcached", promslog.NewNopLogger(), func(_ string, pidFn func() (int, error)) prometheus.Collector {
+		return NewOSCollector(pidFn, "memcached", func() float64 { return 0 }, promslog.NewNopLogger())
+	})
+	if err := c.CheckConstLabels(prometheus.Labels{"pool": "sessions"}); err != nil {
+		t.Errorf("valid label was rejected: %v", err)
+	}
+	for _, name := range []string{"server", "tid"} {
+		if err := c.CheckConstLabels(prometheus.Labels{name: "x"}); err == nil {
+			t.Errorf("label %q clashing with a variable label was accepted", name)
+		}
+	}
+}
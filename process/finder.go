@@ -0,0 +1,267 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package process finds memcached processes on the local host by their
+// listening sockets or their name, for hosts where memcached doesn't write a
+// pid file.
+package process
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tcpListen is the state of listening sockets in /proc/net/tcp.
+	tcpListen = "0A"
+	// unixAcceptCon is the flag of listening sockets in /proc/net/unix.
+	unixAcceptCon = 0x10000
+)
+
+// Process is a memcached process.
+type Process struct {
+	PID int
+	// Server identifies the process across restarts. It is the matching
+	// listen address, or else the first address the process listens on.
+	Server string
+}
+
+// Opts select the processes to find. At least one option must be set.
+type Opts struct {
+	// Listen are ports, host:port addresses or unix socket paths memcached
+	// listens on, one process is found per entry.
+	Listen []string
+	// Name is the process name in /proc/<pid>/comm.
+	Name string
+	// Root is the procfs mount point, /proc by default.
+	Root string
+}
+
+// Finder finds memcached processes in procfs.
+type Finder struct {
+	opts Opts
+}
+
+// NewFinder returns a Finder of the processes selected by opts.
+func NewFinder(opts Opts) *Finder {
+	if opts.Root == "" {
+		opts.Root = "/proc"
+	}
+	return &Finder{opts: opts}
+}
+
+// Find returns the processes currently matching the options, ordered by
+// server. Listening sockets are read from the network namespace of the
+// exporter, so memcached must share it to be found by its listen address.
+func (f *Finder) Find() ([]Process, error) {
+	if len(f.opts.Listen) == 0 && f.opts.Name == "" {
+		return nil, errors.New("neither listen addresses nor a process name given")
+	}
+	sockets, err := f.listeningSockets()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(f.opts.Root)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]Process{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if f.opts.Name != "" {
+			comm, err := os.ReadFile(filepath.Join(f.opts.Root, entry.Name(), "comm"))
+			if err != nil || strings.TrimSpace(string(comm)) != f.opts.Name {
+				continue
+			}
+		}
+		// Processes of other users can't be inspected without privileges,
+		// they are skipped like processes which exited meanwhile.
+		addresses := f.addresses(entry.Name(), sockets)
+
+		if len(f.opts.Listen) == 0 {
+			server := strconv.Itoa(pid)
+			if len(addresses) > 0 {
+				server = addresses[0]
+			}
+			found[server] = Process{PID: pid, Server: server}
+			continue
+		}
+		for _, listen := range f.opts.Listen {
+			if _, ok := found[listen]; ok {
+				continue
+			}
+			if slices.ContainsFunc(addresses, func(a string) bool { return matches(listen, a) }) {
+				found[listen] = Process{PID: pid, Server: listen}
+			}
+		}
+	}
+
+	processes := make([]Process, 0, len(found))
+	for _, p := range found {
+		processes = append(processes, p)
+	}
+	slices.SortFunc(processes, func(a, b Process) int { return strings.Compare(a.Server, b.Server) })
+	return processes, nil
+}
+
+// addresses returns the sorted listen addresses of the sockets open by pid.
+func (f *Finder) addresses(pid string, sockets map[string]string) []string {
+	dir := filepath.Join(f.opts.Root, pid, "fd")
+	fds, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var addresses []string
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(dir, fd.Name()))
+		if err != nil {
+			continue
+		}
+		inode, ok := strings.CutPrefix(link, "socket:[")
+		if !ok {
+			continue
+		}
+		if address, ok := sockets[strings.TrimSuffix(inode, "]")]; ok {
+			addresses = append(addresses, address)
+		}
+	}
+	slices.Sort(addresses)
+	return slices.Compact(addresses)
+}
+
+// listeningSockets returns the addresses of listening TCP and unix sockets by
+// inode.
+func (f *Finder) listeningSockets() (map[string]string, error) {
+	sockets := map[string]string{}
+	for _, name := range []string{"tcp", "tcp6"} {
+		err := readTable(filepath.Join(f.opts.Root, "net", name), func(fields []string) error {
+			if len(fields) < 10 || fields[3] != tcpListen {
+				return nil
+			}
+			address, err := parseAddress(fields[1])
+			if err != nil {
+				return err
+			}
+			sockets[fields[9]] = address
+			return nil
+		})
+		// IPv6 might be disabled.
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	err := readTable(filepath.Join(f.opts.Root, "net", "unix"), func(fields []string) error {
+		if len(fields) < 8 {
+			return nil
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid unix socket flags %q", fields[3])
+		}
+		if flags&unixAcceptCon != 0 {
+			sockets[fields[6]] = fields[7]
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return sockets, nil
+}
+
+// readTable calls fn with the fields of every line of a /proc/net table
+// except the header.
+func readTable(filename string, fn func(fields []string) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan()
+	for scanner.Scan() {
+		if err := fn(strings.Fields(scanner.Text())); err != nil {
+			return fmt.Errorf("parsing %s: %w", filename, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseAddress parses an address of /proc/net/tcp like 0100007F:2BCB, whose
+// IP is made of 32 bit words in host byte order.
+func parseAddress(s string) (string, error) {
+	ipHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+	ip, err := hex.DecodeString(ipHex)
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+	for i := 0; i < len(ip); i += 4 {
+		slices.Reverse(ip[i : i+4])
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+	return net.JoinHostPort(net.IP(ip).String(), strconv.FormatUint(port, 10)), nil
+}
+
+// matches reports whether a listen option matches the address of a socket.
+// Ports match any host.
+func matches(listen, address string) bool {
+	if strings.Contains(listen, "/") {
+		return listen == address
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if !strings.Contains(listen, ":") {
+		return listen == port
+	}
+	wantHost, wantPort, err := net.SplitHostPort(listen)
+	if err != nil || wantPort != port {
+		return false
+	}
+	return wantHost == "" || wantHost == host
+}
+
+// Serves reports whether the server of a found process is the memcached
+// address. TCP addresses are compared by port, as the server is a listen
+// address or port while the address may use a host name.
+func Serves(server, address string) bool {
+	if strings.Contains(server, "/") || strings.Contains(address, "/") {
+		return server == address
+	}
+	port := server
+	if _, p, err := net.SplitHostPort(server); err == nil {
+		port = p
+	}
+	_, want, err := net.SplitHostPort(address)
+	return err == nil && port == want
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	testTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:2BCB 00000000:0000 0A 00000000:00000000 00:00000000 00000000   100        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:2BCC 00000000:0000 0A 00000000:00000000 00:00000000 00000000   100        0 1002 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:2BCB 0100007F:9C40 01 00000000:00000000 00:00000000 00000000   100        0 1003 1 0000000000000000 20 4 30 10 -1
+`
+	testTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:2BCD 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000   100        0 1004 1 0000000000000000 100 0 0 10 0
+`
+	testUnix = `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 2001 /run/memcached/memcached.sock
+0000000000000000: 00000003 00000000 00000000 0001 03 2002 /run/memcached/memcached.sock
+`
+)
+
+// writeProc creates a fake procfs with the given process names and links of
+// open file descriptors.
+func writeProc(t *testing.T, processes map[string]string, fds map[string][]string) string {
+	t.Helper()
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("net/tcp", testTCP)
+	write("net/tcp6", testTCP6)
+	write("net/unix", testUnix)
+	write("self/comm", "memcached_expor\n")
+	for pid, comm := range processes {
+		write(filepath.Join(pid, "comm"), comm+"\n")
+		if err := os.MkdirAll(filepath.Join(root, pid, "fd"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for i, link := range fds[pid] {
+			if err := os.Symlink(link, filepath.Join(root, pid, "fd", string(rune('3'+i)))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return root
+}
+
+func TestFinder(t *testing.T) {
+	root := writeProc(t, map[string]string{
+		"100": "memcached",
+		"200": "memcached",
+		"300": "nginx",
+	}, map[string][]string{
+		"100": {"socket:[1001]", "socket:[2001]", "/dev/null"},
+		"200": {"socket:[1002]", "socket:[1003]"},
+		"300": {"socket:[1004]"},
+	})
+
+	for name, tc := range map[string]struct {
+		opts Opts
+		want []Process
+	}{
+		"Port": {
+			opts: Opts{Listen: []string{"11211"}},
+			want: []Process{{PID: 100, Server: "11211"}},
+		},
+		"Addresses and sockets": {
+			opts: Opts{Listen: []string{"/run/memcached/memcached.sock", "127.0.0.1:11212", "11213", "9999"}},
+			want: []Process{
+				{PID: 100, Server: "/run/memcached/memcached.sock"},
+				{PID: 300, Server: "11213"},
+				{PID: 200, Server: "127.0.0.1:11212"},
+			},
+		},
+		"Other host": {
+			opts: Opts{Listen: []string{"10.0.0.1:11212"}},
+			want: []Process{},
+		},
+		"Listen and name": {
+			opts: Opts{Listen: []string{"11213"}, Name: "memcached"},
+			want: []Process{},
+		},
+		"Name": {
+			opts: Opts{Name: "memcached"},
+			want: []Process{
+				{PID: 100, Server: "/run/memcached/memcached.sock"},
+				{PID: 200, Server: "127.0.0.1:11212"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.opts.Root = root
+			got, err := NewFinder(tc.opts).Find()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("No options", func(t *testing.T) {
+		if _, err := NewFinder(Opts{Root: root}).Find(); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestParseAddress(t *testing.T) {
+	for s, want := range map[string]string{
+		"0100007F:2BCB":                         "127.0.0.1:11211",
+		"00000000:0050":                         "0.0.0.0:80",
+		"00000000000000000000000001000000:2BCB": "[::1]:11211",
+		"0000000000000000FFFF00000100007F:2BCB": "127.0.0.1:11211",
+	} {
+		got, err := parseAddress(s)
+		if err != nil || got != want {
+			t.Errorf("parseAddress(%q) = %q (%v), want %q", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"0100007F", "0100007:2BCB", "0100007F:XYZ"} {
+		if _, err := parseAddress(s); err == nil {
+			t.Errorf("parseAddress(%q) should fail", s)
+		}
+	}
+}
+
+func TestServes(t *testing.T) {
+	for _, tc := range []struct {
+		server, address string
+		want            bool
+	}{
+		{"11211", "localhost:11211", true},
+		{"0.0.0.0:11211", "memcached.local:11211", true},
+		{"[::1]:11211", "[::1]:11211", true},
+		{"11212", "localhost:11211", false},
+		{"/run/memcached.sock", "/run/memcached.sock", true},
+		{"/run/memcached.sock", "localhost:11211", false},
+		{"11211", "localhost", false},
+	} {
+		if got := Serves(tc.server, tc.address); got != tc.want {
+			t.Errorf("Serves(%q, %q) = %v, want %v", tc.server, tc.address, got, tc.want)
+		}
+	}
+}
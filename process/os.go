@@ -0,0 +1,251 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+)
+
+// userHZ is the unit of CPU times in /proc/<pid>/stat, which is 100 on all
+// common platforms.
+const userHZ = 100
+
+// OSCollector exports operating system metrics of a memcached process which
+// the standard process collector lacks: CPU time and context switches per
+// thread, resident memory relative to the configured memory limit, huge pages
+// and socket buffers.
+type OSCollector struct {
+	pidFn       func() (int, error)
+	memoryLimit func() float64
+	logger      *slog.Logger
+	root        string
+
+	threadCPU             *prometheus.Desc
+	threadContextSwitches *prometheus.Desc
+	residentMemoryRatio   *prometheus.Desc
+	anonHugePages         *prometheus.Desc
+	hugetlb               *prometheus.Desc
+	sockets               *prometheus.Desc
+	socketQueued          *prometheus.Desc
+}
+
+// NewOSCollector returns an OSCollector of the process returned by pidFn.
+// memoryLimit returns limit_maxbytes of the server, or 0 if it is unknown, in
+// which case the resident memory ratio is not exported.
+func NewOSCollector(pidFn func() (int, error), namespace string, memoryLimit func() float64, logger *slog.Logger) *OSCollector {
+	return &OSCollector{
+		pidFn:       pidFn,
+		memoryLimit: memoryLimit,
+		logger:      logger,
+		root:        procfs.DefaultMountPoint,
+		threadCPU: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "process", "thread_cpu_seconds_total"),
+			"CPU time spent by a thread of the process.",
+			[]string{"tid", "thread", "mode"},
+			nil,
+		),
+		threadContextSwitches: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "process", "thread_context_switches_total"),
+			"Context switches of a thread of the process, by type.",
+			[]string{"tid", "thread", "type"},
+			nil,
+		),
+		residentMemoryRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "process", "resident_memory_limit_ratio"),
+			"Resident memory of the process relative to the memory limit of the server, limit_maxbytes.",
+			nil,
+			nil,
+		),
+		anonHugePages: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "process", "anon_huge_pages_bytes"),
+			"Resident memory of the process backed by transparent huge pages.",
+			nil,
+			nil,
+		),
+		hugetlb: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "process", "hugetlb_bytes"),
+			"Memory of the process backed by hugetlbfs pages.",
+			nil,
+			nil,
+		),
+		sockets: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "process", "tcp_sockets"),
+			"Number of TCP sockets open by the process.",
+			nil,
+			nil,
+		),
+		socketQueued: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "process", "tcp_socket_queued_bytes"),
+			"Bytes queued in the send and receive buffers of the TCP sockets of the process.",
+			[]string{"queue"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *OSCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.threadCPU
+	ch <- c.threadContextSwitches
+	ch <- c.residentMemoryRatio
+	ch <- c.anonHugePages
+	ch <- c.hugetlb
+	ch <- c.sockets
+	ch <- c.socketQueued
+}
+
+// Collect implements prometheus.Collector.
+func (c *OSCollector) Collect(ch chan<- prometheus.Metric) {
+	pid, err := c.pidFn()
+	if err != nil {
+		c.logger.Error("Failed to get the memcached PID", "err", err)
+		return
+	}
+	fs, err := procfs.NewFS(c.root)
+	if err != nil {
+		c.logger.Error("Failed to open procfs", "err", err)
+		return
+	}
+	proc, err := fs.Proc(pid)
+	if err != nil {
+		c.logger.Error("Failed to read memcached process", "pid", pid, "err", err)
+		return
+	}
+
+	if err := c.collectThreads(ch, fs, pid); err != nil {
+		c.logger.Error("Failed to read memcached threads", "pid", pid, "err", err)
+	}
+	if err := c.collectMemory(ch, proc); err != nil {
+		c.logger.Error("Failed to read memcached memory", "pid", pid, "err", err)
+	}
+	if err := c.collectSockets(ch, proc); err != nil {
+		c.logger.Error("Failed to read memcached sockets", "pid", pid, "err", err)
+	}
+}
+
+func (c *OSCollector) collectThreads(ch chan<- prometheus.Metric, fs procfs.FS, pid int) error {
+	threads, err := fs.AllThreads(pid)
+	if err != nil {
+		return err
+	}
+	for _, thread := range threads {
+		// Threads might exit while they are read.
+		stat, err := thread.Stat()
+		if err != nil {
+			continue
+		}
+		status, err := thread.NewStatus()
+		if err != nil {
+			continue
+		}
+		tid := strconv.Itoa(thread.PID)
+		ch <- prometheus.MustNewConstMetric(c.threadCPU, prometheus.CounterValue, float64(stat.UTime)/userHZ, tid, stat.Comm, "user")
+		ch <- prometheus.MustNewConstMetric(c.threadCPU, prometheus.CounterValue, float64(stat.STime)/userHZ, tid, stat.Comm, "system")
+		ch <- prometheus.MustNewConstMetric(c.threadContextSwitches, prometheus.CounterValue, float64(status.VoluntaryCtxtSwitches), tid, stat.Comm, "voluntary")
+		ch <- prometheus.MustNewConstMetric(c.threadContextSwitches, prometheus.CounterValue, float64(status.NonVoluntaryCtxtSwitches), tid, stat.Comm, "involuntary")
+	}
+	return nil
+}
+
+func (c *OSCollector) collectMemory(ch chan<- prometheus.Metric, proc procfs.Proc) error {
+	stat, err := proc.Stat()
+	if err != nil {
+		return err
+	}
+	if limit := c.memoryLimit(); limit > 0 {
+		ch <- prometheus.MustNewConstMetric(c.residentMemoryRatio, prometheus.GaugeValue, float64(stat.ResidentMemory())/limit)
+	}
+
+	// procfs doesn't parse the huge page fields of smaps_rollup.
+	rollup, err := os.ReadFile(filepath.Join(c.root, strconv.Itoa(proc.PID), "smaps_rollup"))
+	if err != nil {
+		return err
+	}
+	sizes := parseSmapsRollup(rollup)
+	ch <- prometheus.MustNewConstMetric(c.anonHugePages, prometheus.GaugeValue, sizes["AnonHugePages"])
+	ch <- prometheus.MustNewConstMetric(c.hugetlb, prometheus.GaugeValue, sizes["Shared_Hugetlb"]+sizes["Private_Hugetlb"])
+	return nil
+}
+
+// parseSmapsRollup returns the sizes in bytes of /proc/<pid>/smaps_rollup.
+func parseSmapsRollup(data []byte) map[string]float64 {
+	sizes := map[string]float64{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		kb, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 64)
+		if err != nil {
+			continue
+		}
+		sizes[key] = kb * 1024
+	}
+	return sizes
+}
+
+func (c *OSCollector) collectSockets(ch chan<- prometheus.Metric, proc procfs.Proc) error {
+	targets, err := proc.FileDescriptorTargets()
+	if err != nil {
+		return err
+	}
+	inodes := map[uint64]bool{}
+	for _, target := range targets {
+		if inode, ok := strings.CutPrefix(target, "socket:["); ok {
+			if n, err := strconv.ParseUint(strings.TrimSuffix(inode, "]"), 10, 64); err == nil {
+				inodes[n] = true
+			}
+		}
+	}
+
+	// The network tables of the process show the sockets of its network
+	// namespace.
+	netFS, err := procfs.NewFS(filepath.Join(c.root, strconv.Itoa(proc.PID)))
+	if err != nil {
+		return err
+	}
+	var sockets, sendQueue, receiveQueue uint64
+	for _, read := range []func() (procfs.NetTCP, error){netFS.NetTCP, netFS.NetTCP6} {
+		lines, err := read()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if !inodes[line.Inode] {
+				continue
+			}
+			sockets++
+			sendQueue += line.TxQueue
+			receiveQueue += line.RxQueue
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.sockets, prometheus.GaugeValue, float64(sockets))
+	ch <- prometheus.MustNewConstMetric(c.socketQueued, prometheus.GaugeValue, float64(sendQueue), "send")
+	ch <- prometheus.MustNewConstMetric(c.socketQueued, prometheus.GaugeValue, float64(receiveQueue), "receive")
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+)
+
+// testTCPQueues has a listening socket, a connection with queued data, both
+// owned by the process, and a connection of another process.
+const testTCPQueues = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:2BCB 00000000:0000 0A 00000000:00000000 00:00000000 00000000   100        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:2BCB 0100007F:9C40 01 00000010:00000020 00:00000000 00000000   100        0 1002 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:2BCB 0100007F:9C41 01 00000100:00000200 00:00000000 00000000   100        0 1003 1 0000000000000000 20 4 30 10 -1
+`
+
+// testStat returns a /proc/<pid>/stat line with the given CPU ticks and
+// resident pages.
+func testStat(pid int, comm string, utime, stime, rss int) string {
+	return fmt.Sprintf("%d (%s) S 1 %d %d 0 -1 4194560 1000 0 0 0 %d %d 0 0 20 0 3 0 12345 100000000 %d "+
+		"18446744073709551615 1 1 0 0 0 0 0 4096 16896 0 0 0 17 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n", pid, comm, pid, pid, utime, stime, rss)
+}
+
+func TestOSCollector(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"100/stat":            testStat(100, "memcached", 150, 50, 10),
+		"100/task/100/stat":   testStat(100, "memcached", 100, 40, 10),
+		"100/task/100/status": "Name:\tmemcached\nvoluntary_ctxt_switches:\t10\nnonvoluntary_ctxt_switches:\t2\n",
+		"100/task/101/stat":   testStat(101, "mc-worker", 50, 10, 10),
+		"100/task/101/status": "Name:\tmc-worker\nvoluntary_ctxt_switches:\t300\nnonvoluntary_ctxt_switches:\t40\n",
+		"100/smaps_rollup":    "00400000-7ffc1a3e2000 ---p 00000000 00:00 0    [rollup]\nRss:    40960 kB\nAnonHugePages:    2048 kB\nShared_Hugetlb:    0 kB\nPrivate_Hugetlb:    4096 kB\n",
+		"100/net/tcp":         testTCPQueues,
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "100", "fd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for fd, link := range map[string]string{"3": "socket:[1001]", "4": "socket:[1002]", "5": "/dev/null"} {
+		if err := os.Symlink(link, filepath.Join(root, "100", "fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	limit := func() float64 { return float64(20 * os.Getpagesize()) }
+	c := NewOSCollector(func() (int, error) { return 100, nil }, "memcached", limit, promslog.NewNopLogger())
+	c.root = root
+
+	want := `
+# HELP memcached_process_anon_huge_pages_bytes Resident memory of the process backed by transparent huge pages.
+# TYPE memcached_process_anon_huge_pages_bytes gauge
+memcached_process_anon_huge_pages_bytes 2.097152e+06
+# HELP memcached_process_hugetlb_bytes Memory of the process backed by hugetlbfs pages.
+# TYPE memcached_process_hugetlb_bytes gauge
+memcached_process_hugetlb_bytes 4.194304e+06
+# HELP memcached_process_resident_memory_limit_ratio Resident memory of the process relative to the memory limit of the server, limit_maxbytes.
+# TYPE memcached_process_resident_memory_limit_ratio gauge
+memcached_process_resident_memory_limit_ratio 0.5
+# HELP memcached_process_tcp_socket_queued_bytes Bytes queued in the send and receive buffers of the TCP sockets of the process.
+# TYPE memcached_process_tcp_socket_queued_bytes gauge
+memcached_process_tcp_socket_queued_bytes{queue="receive"} 32
+memcached_process_tcp_socket_queued_bytes{queue="send"} 16
+# HELP memcached_process_tcp_sockets Number of TCP sockets open by the process.
+# TYPE memcached_process_tcp_sockets gauge
+memcached_process_tcp_sockets 2
+# HELP memcached_process_thread_context_switches_total Context switches of a thread of the process, by type.
+# TYPE memcached_process_thread_context_switches_total counter
+memcached_process_thread_context_switches_total{thread="mc-worker",tid="101",type="involuntary"} 40
+memcached_process_thread_context_switches_total{thread="mc-worker",tid="101",type="voluntary"} 300
+memcached_process_thread_context_switches_total{thread="memcached",tid="100",type="involuntary"} 2
+memcached_process_thread_context_switches_total{thread="memcached",tid="100",type="voluntary"} 10
+# HELP memcached_process_thread_cpu_seconds_total CPU time spent by a thread of the process.
+# TYPE memcached_process_thread_cpu_seconds_total counter
+memcached_process_thread_cpu_seconds_total{mode="system",thread="mc-worker",tid="101"} 0.1
+memcached_process_thread_cpu_seconds_total{mode="system",thread="memcached",tid="100"} 0.4
+memcached_process_thread_cpu_seconds_total{mode="user",thread="mc-worker",tid="101"} 0.5
+memcached_process_thread_cpu_seconds_total{mode="user",thread="memcached",tid="100"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
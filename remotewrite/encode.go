@@ -0,0 +1,193 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotewrite
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the remote write 1.0 protobuf messages.
+const (
+	fieldWriteRequestTimeseries = 1
+	fieldWriteRequestMetadata   = 3
+
+	fieldTimeSeriesLabels  = 1
+	fieldTimeSeriesSamples = 2
+
+	fieldLabelName  = 1
+	fieldLabelValue = 2
+
+	fieldSampleValue     = 1
+	fieldSampleTimestamp = 2
+
+	fieldMetadataType = 1
+	fieldMetadataName = 2
+	fieldMetadataHelp = 4
+)
+
+// Metric types of the remote write metadata.
+const (
+	metadataUnknown   = 0
+	metadataCounter   = 1
+	metadataGauge     = 2
+	metadataHistogram = 3
+	metadataSummary   = 5
+)
+
+type label struct {
+	name, value string
+}
+
+// encoder builds a remote write 1.0 WriteRequest.
+type encoder struct {
+	buf     []byte
+	labels  []label
+	samples int
+}
+
+// encode returns the WriteRequest for the gathered metric families with
+// labels added to every series, and the number of samples in it. Series are
+// timestamped with now unless the metric has its own timestamp. Native
+// histograms are sent as their classic buckets only.
+func encode(families []*dto.MetricFamily, labels map[string]string, now int64) ([]byte, int) {
+	e := &encoder{}
+	for name, value := range labels {
+		e.labels = append(e.labels, label{name, value})
+	}
+
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			ts := now
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			ls := e.seriesLabels(m.GetLabel())
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				e.series(name, ls, m.GetCounter().GetValue(), ts)
+			case dto.MetricType_GAUGE:
+				e.series(name, ls, m.GetGauge().GetValue(), ts)
+			case dto.MetricType_UNTYPED:
+				e.series(name, ls, m.GetUntyped().GetValue(), ts)
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					e.series(name, append(ls, label{"quantile", formatFloat(q.GetQuantile())}), q.GetValue(), ts)
+				}
+				e.series(name+"_sum", ls, s.GetSampleSum(), ts)
+				e.series(name+"_count", ls, float64(s.GetSampleCount()), ts)
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				infSeen := false
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), 1) {
+						infSeen = true
+					}
+					e.series(name+"_bucket", append(ls, label{"le", formatFloat(b.GetUpperBound())}), float64(b.GetCumulativeCount()), ts)
+				}
+				if !infSeen {
+					e.series(name+"_bucket", append(ls, label{"le", "+Inf"}), float64(h.GetSampleCount()), ts)
+				}
+				e.series(name+"_sum", ls, h.GetSampleSum(), ts)
+				e.series(name+"_count", ls, float64(h.GetSampleCount()), ts)
+			}
+		}
+		e.metadata(mf)
+	}
+	return e.buf, e.samples
+}
+
+// seriesLabels merges the labels of a metric with the configured ones, the
+// metric's own labels taking precedence.
+func (e *encoder) seriesLabels(pairs []*dto.LabelPair) []label {
+	ls := make([]label, 0, len(pairs)+len(e.labels))
+	for _, p := range pairs {
+		ls = append(ls, label{p.GetName(), p.GetValue()})
+	}
+	for _, l := range e.labels {
+		if !slices.ContainsFunc(ls, func(o label) bool { return o.name == l.name }) {
+			ls = append(ls, l)
+		}
+	}
+	return ls
+}
+
+func (e *encoder) series(name string, labels []label, value float64, ts int64) {
+	ls := append([]label{{"__name__", name}}, labels...)
+	slices.SortFunc(ls, func(a, b label) int { return strings.Compare(a.name, b.name) })
+
+	var b []byte
+	for _, l := range ls {
+		var lb []byte
+		lb = protowire.AppendTag(lb, fieldLabelName, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.name)
+		lb = protowire.AppendTag(lb, fieldLabelValue, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.value)
+		b = protowire.AppendTag(b, fieldTimeSeriesLabels, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+	var sb []byte
+	sb = protowire.AppendTag(sb, fieldSampleValue, protowire.Fixed64Type)
+	sb = protowire.AppendFixed64(sb, math.Float64bits(value))
+	sb = protowire.AppendTag(sb, fieldSampleTimestamp, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, uint64(ts))
+	b = protowire.AppendTag(b, fieldTimeSeriesSamples, protowire.BytesType)
+	b = protowire.AppendBytes(b, sb)
+
+	e.buf = protowire.AppendTag(e.buf, fieldWriteRequestTimeseries, protowire.BytesType)
+	e.buf = protowire.AppendBytes(e.buf, b)
+	e.samples++
+}
+
+func (e *encoder) metadata(mf *dto.MetricFamily) {
+	t := metadataUnknown
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		t = metadataCounter
+	case dto.MetricType_GAUGE:
+		t = metadataGauge
+	case dto.MetricType_HISTOGRAM:
+		t = metadataHistogram
+	case dto.MetricType_SUMMARY:
+		t = metadataSummary
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, fieldMetadataType, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(t))
+	b = protowire.AppendTag(b, fieldMetadataName, protowire.BytesType)
+	b = protowire.AppendString(b, mf.GetName())
+	b = protowire.AppendTag(b, fieldMetadataHelp, protowire.BytesType)
+	b = protowire.AppendString(b, mf.GetHelp())
+	e.buf = protowire.AppendTag(e.buf, fieldWriteRequestMetadata, protowire.BytesType)
+	e.buf = protowire.AppendBytes(e.buf, b)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
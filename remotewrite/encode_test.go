@@ -0,0 +1,115 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decode returns the series of a WriteRequest formatted like the text
+// exposition format, sorted.
+func decode(t *testing.T, b []byte) []string {
+	t.Helper()
+	var series []string
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.BytesType {
+			t.Fatalf("unexpected wire type %v", typ)
+		}
+		v, n := protowire.ConsumeBytes(b)
+		b = b[n:]
+		if num == fieldWriteRequestTimeseries {
+			series = append(series, decodeSeries(t, v))
+		}
+	}
+	sort.Strings(series)
+	return series
+}
+
+func decodeSeries(t *testing.T, b []byte) string {
+	var (
+		name   string
+		labels []string
+		value  string
+	)
+	for len(b) > 0 {
+		num, _, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		b = b[n:]
+		fields := map[protowire.Number][]byte{}
+		for len(v) > 0 {
+			fnum, ftyp, fn := protowire.ConsumeTag(v)
+			v = v[fn:]
+			fn = protowire.ConsumeFieldValue(fnum, ftyp, v)
+			fields[fnum] = v[:fn]
+			v = v[fn:]
+		}
+		switch num {
+		case fieldTimeSeriesLabels:
+			ln, _ := protowire.ConsumeString(fields[fieldLabelName])
+			lv, _ := protowire.ConsumeString(fields[fieldLabelValue])
+			if ln == "__name__" {
+				name = lv
+			} else {
+				labels = append(labels, ln+"="+strconv.Quote(lv))
+			}
+		case fieldTimeSeriesSamples:
+			f, _ := protowire.ConsumeFixed64(fields[fieldSampleValue])
+			value = strconv.FormatFloat(math.Float64frombits(f), 'g', -1, 64)
+		default:
+			t.Fatalf("unexpected time series field %v", num)
+		}
+	}
+	return name + "{" + strings.Join(labels, ",") + "} " + value
+}
+
+func TestEncode(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total", Help: "Test counter."}, []string{"op", "job"})
+	c.WithLabelValues("get", "custom").Add(3)
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Help: "Test histogram.", Buckets: []float64{1}})
+	h.Observe(0.5)
+	h.Observe(2)
+	registry.MustRegister(c, h)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, samples := encode(families, map[string]string{"job": "memcached", "instance": "a"}, 1000)
+	if samples != 5 {
+		t.Errorf("want 5 samples, got %d", samples)
+	}
+
+	want := []string{
+		`test_seconds_bucket{instance="a",job="memcached",le="+Inf"} 2`,
+		`test_seconds_bucket{instance="a",job="memcached",le="1"} 1`,
+		`test_seconds_count{instance="a",job="memcached"} 2`,
+		`test_seconds_sum{instance="a",job="memcached"} 2.5`,
+		`test_total{instance="a",job="custom",op="get"} 3`,
+	}
+	got := decode(t, b)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("want series\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remotewrite periodically gathers the metrics of the exporter and
+// sends them to a Prometheus remote write endpoint.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/version"
+)
+
+const subsystem = "remote_write"
+
+// Opts configures the remote write mode.
+type Opts struct {
+	// URL of the remote write endpoint.
+	URL string
+	// Interval between two gathers.
+	Interval time.Duration
+	// Timeout of a single request.
+	Timeout time.Duration
+	// Labels are added to every series, e.g. job and instance.
+	Labels map[string]string
+	// QueueCapacity is the number of requests kept while the endpoint is
+	// unavailable. Once the queue is full the oldest request is dropped.
+	QueueCapacity int
+	// MaxRetries of a request failing with a network error, 429 or 5xx
+	// before it is dropped.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubling up to
+	// MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// HTTPClientConfig holds the basic auth, authorization and TLS settings
+	// of the endpoint.
+	HTTPClientConfig promconfig.HTTPClientConfig
+}
+
+// Writer sends the metrics of a gatherer to a remote write endpoint. It
+// implements prometheus.Collector for its own queue and send metrics.
+type Writer struct {
+	gatherer prometheus.Gatherer
+	logger   *slog.Logger
+	opts     Opts
+	client   *http.Client
+
+	mu      sync.Mutex
+	queue   []request
+	seq     uint64
+	pending chan struct{}
+
+	queueLength     *prometheus.Desc
+	queueCapacity   *prometheus.Desc
+	samplesSent     prometheus.Counter
+	sendFailures    prometheus.Counter
+	requestsDropped *prometheus.CounterVec
+}
+
+// New returns a writer for the metrics of gatherer.
+func New(gatherer prometheus.Gatherer, logger *slog.Logger, opts Opts) (*Writer, error) {
+	if err := opts.HTTPClientConfig.Validate(); err != nil {
+		return nil, err
+	}
+	client, err := promconfig.NewClientFromConfig(opts.HTTPClientConfig, "remote_write")
+	if err != nil {
+		return nil, err
+	}
+	client.Timeout = opts.Timeout
+
+	return &Writer{
+		gatherer: gatherer,
+		logger:   logger,
+		opts:     opts,
+		client:   client,
+		pending:  make(chan struct{}, 1),
+		queueLength: prometheus.NewDesc(
+			prometheus.BuildFQName("memcached_exporter", subsystem, "queue_length"),
+			"Number of requests waiting to be sent to the remote write endpoint.",
+			nil,
+			nil,
+		),
+		queueCapacity: prometheus.NewDesc(
+			prometheus.BuildFQName("memcached_exporter", subsystem, "queue_capacity"),
+			"Maximum number of requests waiting to be sent to the remote write endpoint.",
+			nil,
+			nil,
+		),
+		samplesSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "memcached_exporter",
+			Subsystem: subsystem,
+			Name:      "samples_sent_total",
+			Help:      "Total number of samples accepted by the remote write endpoint.",
+		}),
+		sendFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "memcached_exporter",
+			Subsystem: subsystem,
+			Name:      "send_failures_total",
+			Help:      "Total number of failed requests to the remote write endpoint, including retries.",
+		}),
+		requestsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "memcached_exporter",
+			Subsystem: subsystem,
+			Name:      "requests_dropped_total",
+			Help:      "Total number of requests dropped without being sent, because the queue was full or they failed permanently.",
+		}, []string{"reason"}),
+	}, nil
+}
+
+// Describe describes the metrics of the writer. It implements
+// prometheus.Collector.
+func (w *Writer) Describe(ch chan<- *prometheus.Desc) {
+	ch <- w.queueLength
+	ch <- w.queueCapacity
+	w.samplesSent.Describe(ch)
+	w.sendFailures.Describe(ch)
+	w.requestsDropped.Describe(ch)
+}
+
+// Collect delivers the metrics of the writer. It implements
+// prometheus.Collector.
+func (w *Writer) Collect(ch chan<- prometheus.Metric) {
+	w.mu.Lock()
+	n := len(w.queue)
+	w.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(w.queueLength, prometheus.GaugeValue, float64(n))
+	ch <- prometheus.MustNewConstMetric(w.queueCapacity, prometheus.GaugeValue, float64(w.opts.QueueCapacity))
+	w.samplesSent.Collect(ch)
+	w.sendFailures.Collect(ch)
+	w.requestsDropped.Collect(ch)
+}
+
+// Start gathers and queues the metrics every interval and sends them until
+// ctx is cancelled.
+func (w *Writer) Start(ctx context.Context) {
+	go w.gatherLoop(ctx)
+	go w.sendLoop(ctx)
+}
+
+func (w *Writer) gatherLoop(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		w.gather()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Writer) gather() {
+	families, err := w.gatherer.Gather()
+	if err != nil {
+		// Gather returns whatever it could collect next to the error.
+		w.logger.Warn("Error gathering metrics for remote write", "err", err)
+	}
+	req, samples := encode(families, w.opts.Labels, time.Now().UnixMilli())
+	if samples == 0 {
+		return
+	}
+	w.enqueue(snappy.Encode(nil, req), samples)
+}
+
+// request is a snappy compressed WriteRequest waiting to be sent.
+type request struct {
+	seq     uint64
+	body    []byte
+	samples int
+}
+
+func (w *Writer) enqueue(body []byte, samples int) {
+	w.mu.Lock()
+	if len(w.queue) >= w.opts.QueueCapacity {
+		w.queue = w.queue[1:]
+		w.requestsDropped.WithLabelValues("queue_full").Inc()
+		w.logger.Warn("Remote write queue full, dropped the oldest request", "capacity", w.opts.QueueCapacity)
+	}
+	w.seq++
+	w.queue = append(w.queue, request{seq: w.seq, body: body, samples: samples})
+	w.mu.Unlock()
+
+	select {
+	case w.pending <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the oldest queued request without removing it.
+func (w *Writer) next() (request, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) == 0 {
+		return request{}, false
+	}
+	return w.queue[0], true
+}
+
+// done removes r from the queue unless it was already dropped.
+func (w *Writer) done(r request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) > 0 && w.queue[0].seq == r.seq {
+		w.queue = w.queue[1:]
+	}
+}
+
+func (w *Writer) sendLoop(ctx context.Context) {
+	for {
+		r, ok := w.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.pending:
+			}
+			continue
+		}
+		if err := w.sendWithRetries(ctx, r); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.requestsDropped.WithLabelValues("send_failed").Inc()
+			w.logger.Error("Dropped remote write request", "url", w.opts.URL, "err", err)
+		} else {
+			w.samplesSent.Add(float64(r.samples))
+		}
+		w.done(r)
+	}
+}
+
+func (w *Writer) sendWithRetries(ctx context.Context, r request) error {
+	backoff := w.opts.MinBackoff
+	for attempt := 0; ; attempt++ {
+		err := w.send(ctx, r.body)
+		if err == nil {
+			return nil
+		}
+		w.sendFailures.Inc()
+		if _, ok := err.(retryableError); !ok || attempt >= w.opts.MaxRetries {
+			return err
+		}
+		w.logger.Debug("Retrying remote write request", "attempt", attempt+1, "backoff", backoff, "err", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, w.opts.MaxBackoff)
+	}
+}
+
+// retryableError is returned for network errors and responses the endpoint
+// may accept later.
+type retryableError struct {
+	error
+}
+
+func (w *Writer) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "memcached_exporter/"+version.Version)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return retryableError{err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("server returned HTTP status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5 {
+		return retryableError{err}
+	}
+	return err
+}
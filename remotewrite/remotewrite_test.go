@@ -0,0 +1,187 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/promslog"
+)
+
+func testOpts(url string) Opts {
+	return Opts{
+		URL:              url,
+		Interval:         time.Hour,
+		Timeout:          time.Second,
+		Labels:           map[string]string{"job": "memcached"},
+		QueueCapacity:    2,
+		MaxRetries:       3,
+		MinBackoff:       time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		HTTPClientConfig: promconfig.DefaultHTTPClientConfig,
+	}
+}
+
+func testRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "memcached_up", Help: "Up."})
+	g.Set(1)
+	registry.MustRegister(g)
+	return registry
+}
+
+func waitFor(t *testing.T, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		received := make(chan []string, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" {
+				http.Error(w, "bad headers", http.StatusBadRequest)
+				return
+			}
+			compressed, _ := io.ReadAll(r.Body)
+			b, err := snappy.Decode(nil, compressed)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			received <- decode(t, b)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		opts := testOpts(srv.URL)
+		opts.HTTPClientConfig.BasicAuth = &promconfig.BasicAuth{Username: "user", Password: "secret"}
+		w, err := New(testRegistry(), promslog.NewNopLogger(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w.Start(ctx)
+
+		select {
+		case got := <-received:
+			if len(got) != 1 || got[0] != `memcached_up{job="memcached"} 1` {
+				t.Errorf("unexpected series %v", got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for remote write")
+		}
+		waitFor(t, func() bool { return testutil.ToFloat64(w.samplesSent) == 1 })
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Parallel()
+
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if requests.Add(1) <= 2 {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		w, err := New(testRegistry(), promslog.NewNopLogger(), testOpts(srv.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w.Start(ctx)
+
+		waitFor(t, func() bool { return testutil.ToFloat64(w.samplesSent) == 1 })
+		if n := testutil.ToFloat64(w.sendFailures); n != 2 {
+			t.Errorf("want 2 send failures, got %v", n)
+		}
+	})
+
+	t.Run("Permanent failure", func(t *testing.T) {
+		t.Parallel()
+
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			http.Error(w, "out of order sample", http.StatusBadRequest)
+		}))
+		defer srv.Close()
+
+		w, err := New(testRegistry(), promslog.NewNopLogger(), testOpts(srv.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w.Start(ctx)
+
+		waitFor(t, func() bool { return testutil.ToFloat64(w.requestsDropped.WithLabelValues("send_failed")) == 1 })
+		if n := requests.Load(); n != 1 {
+			t.Errorf("want a single request without retries, got %d", n)
+		}
+	})
+
+	t.Run("Queue full", func(t *testing.T) {
+		t.Parallel()
+
+		w, err := New(testRegistry(), promslog.NewNopLogger(), testOpts("http://localhost:0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 3 {
+			w.gather()
+		}
+
+		want := `
+# HELP memcached_exporter_remote_write_queue_length Number of requests waiting to be sent to the remote write endpoint.
+# TYPE memcached_exporter_remote_write_queue_length gauge
+memcached_exporter_remote_write_queue_length 2
+# HELP memcached_exporter_remote_write_requests_dropped_total Total number of requests dropped without being sent, because the queue was full or they failed permanently.
+# TYPE memcached_exporter_remote_write_requests_dropped_total counter
+memcached_exporter_remote_write_requests_dropped_total{reason="queue_full"} 1
+`
+		if err := testutil.CollectAndCompare(w, strings.NewReader(want),
+			"memcached_exporter_remote_write_queue_length", "memcached_exporter_remote_write_requests_dropped_total"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
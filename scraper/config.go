@@ -0,0 +1,273 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/config"
+	"github.com/prometheus/memcached_exporter/health"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+var (
+	errUnknownModule  = errors.New("unknown or disabled module")
+	errNotListed      = errors.New("target is not listed in the config file")
+	errTooManyTargets = errors.New("too many targets")
+)
+
+// module is the resolved configuration of a module.
+type module struct {
+	prober    string
+	timeout   time.Duration
+	tlsConfig *tls.Config
+	keyPrefix string
+	derived   bool
+	latency   bool
+	hotKeys   bool
+	evictions bool
+	keyspace  bool
+	// constLabels of the scraped target.
+	constLabels prometheus.Labels
+	// baseline of the scraped target, if any.
+	baseline *exporter.Baseline
+}
+
+// ApplyConfig replaces the modules, targets and limits of the configuration
+// file. Collectors kept for the previous configuration are dropped.
+func (s *Scraper) ApplyConfig(c *config.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range c.Targets {
+		if _, ok := s.module(c, t.Module); t.Module != "" && !ok {
+			return fmt.Errorf("target %q: %w %q", t.Address, errUnknownModule, t.Module)
+		}
+	}
+	if err := s.checkLabels(c); err != nil {
+		return err
+	}
+
+	s.config = c
+	for k, t := range s.targets {
+		t.cancel()
+		delete(s.targets, k)
+	}
+	return nil
+}
+
+// resolve applies the configuration file to a scrape of target. The module
+// and pool of a listed target are used if the scrape doesn't set them.
+func (s *Scraper) resolve(target, moduleName, pool string) (targetKey, module, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		t      config.Target
+		listed bool
+	)
+	if s.config != nil {
+		t, listed = s.config.Target(target)
+		if !listed && s.config.Limits.RestrictTargets {
+			return targetKey{}, module{}, fmt.Errorf("%w: %q", errNotListed, target)
+		}
+		if moduleName == "" {
+			moduleName = t.Module
+		}
+		if pool == "" {
+			pool = t.Pool
+		}
+	}
+	// Every polled target keeps a goroutine busy, only poll known targets.
+	if !listed && s.pollOpts != nil {
+		return targetKey{}, module{}, fmt.Errorf("%w, required for polling: %q", errNotListed, target)
+	}
+	if moduleName == "" {
+		moduleName = config.ProberDefault
+	}
+
+	m, ok := s.module(s.config, moduleName)
+	if !ok {
+		return targetKey{}, module{}, fmt.Errorf("%w %q", errUnknownModule, moduleName)
+	}
+	m.constLabels = s.labelsOf(t)
+	m.baseline = s.baselinesOf(s.config).For(pool, target)
+	return targetKey{module: moduleName, target: target, pool: pool}, m, nil
+}
+
+// ReadyTargets returns the targets of the configuration file with the
+// timeout and TLS configuration of their module, for readiness checks.
+func (s *Scraper) ReadyTargets() []health.Target {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config == nil {
+		return nil
+	}
+	targets := make([]health.Target, 0, len(s.config.Targets))
+	for _, t := range s.config.Targets {
+		name := t.Module
+		if name == "" {
+			name = config.ProberDefault
+		}
+		// ApplyConfig rejects targets of unknown modules.
+		m, _ := s.module(s.config, name)
+		targets = append(targets, health.Target{Address: t.Address, Timeout: m.timeout, TLSConfig: m.tlsConfig})
+	}
+	return targets
+}
+
+// module returns the module called name. Modules of the configuration file
+// take precedence over the built-in modules set up by flags.
+func (s *Scraper) module(c *config.Config, name string) (module, bool) {
+	if c != nil {
+		if m, ok := c.Modules[name]; ok {
+			resolved := module{
+				prober:    m.Prober,
+				timeout:   m.TimeoutOr(s.timeout),
+				tlsConfig: s.tlsConfig,
+				keyPrefix: m.KeyPrefix,
+				derived:   s.derived,
+				latency:   s.latency,
+				hotKeys:   m.Collectors.HotKeys,
+				evictions: m.Collectors.Evictions,
+				keyspace:  m.Collectors.Keyspace,
+			}
+			if m.TLSConfig != nil {
+				resolved.tlsConfig = m.TLS()
+			}
+			if m.Collectors.Derived != nil {
+				resolved.derived = *m.Collectors.Derived
+			}
+			if m.Collectors.Latency != nil {
+				resolved.latency = *m.Collectors.Latency
+			}
+			return resolved, true
+		}
+	}
+
+	m := module{prober: name, timeout: s.timeout, tlsConfig: s.tlsConfig}
+	switch name {
+	case config.ProberDefault:
+		m.derived = s.derived
+		m.latency = s.latency
+	case config.ProberCanary:
+		m.keyPrefix = s.canaryKeyPrefix
+	case config.ProberMeta:
+		m.keyPrefix = s.metaKeyPrefix
+	default:
+		return module{}, false
+	}
+	if m.prober != config.ProberDefault && m.keyPrefix == "" {
+		return module{}, false
+	}
+	return m, true
+}
+
+// CheckConstLabels returns an error if the constant labels can't be added to
+// the metrics of every module, because they are invalid or clash with a
+// variable label of one of its collectors.
+func (s *Scraper) CheckConstLabels() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkLabels(s.config)
+}
+
+// checkLabels checks the global labels against every module of c, which
+// unlisted targets may choose, and the labels of every target of c against
+// its module.
+func (s *Scraper) checkLabels(c *config.Config) error {
+	// Any baseline enables the drift metrics.
+	var baseline *exporter.Baseline
+	for _, b := range s.baselinesOf(c) {
+		baseline = b
+		break
+	}
+
+	names := []string{config.ProberDefault, config.ProberCanary, config.ProberMeta}
+	if c != nil {
+		names = append(names, slices.Sorted(maps.Keys(c.Modules))...)
+	}
+	for _, name := range names {
+		m, ok := s.module(c, name)
+		if !ok {
+			continue
+		}
+		m.constLabels = s.constLabels
+		m.baseline = baseline
+		if err := checkCollectors(s.newCollectors(targetKey{module: name}, m)); err != nil {
+			return fmt.Errorf("module %q: invalid labels: %w", name, err)
+		}
+	}
+	if c == nil {
+		return nil
+	}
+	for _, t := range c.Targets {
+		name := t.Module
+		if name == "" {
+			name = config.ProberDefault
+		}
+		m, _ := s.module(c, name)
+		m.constLabels = s.labelsOf(t)
+		m.baseline = baseline
+		if err := checkCollectors(s.newCollectors(targetKey{module: name, target: t.Address}, m)); err != nil {
+			return fmt.Errorf("target %q: invalid labels: %w", t.Address, err)
+		}
+	}
+	return nil
+}
+
+// checkCollectors registers cs, which are not started, to check their
+// metric descriptions.
+func checkCollectors(cs collectors, _ func(context.Context)) error {
+	return prometheus.NewPedanticRegistry().Register(cs)
+}
+
+// baselinesOf returns the baselines of c, or else those of the flags.
+func (s *Scraper) baselinesOf(c *config.Config) exporter.Baselines {
+	if c != nil && c.Baselines() != nil {
+		return c.Baselines()
+	}
+	return s.baselines
+}
+
+// labelsOf returns the global constant labels merged with those of t.
+func (s *Scraper) labelsOf(t config.Target) prometheus.Labels {
+	if len(t.Labels) == 0 {
+		return s.constLabels
+	}
+	labels := prometheus.Labels{}
+	maps.Copy(labels, s.constLabels)
+	maps.Copy(labels, t.Labels)
+	return labels
+}
+
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, errNotListed):
+		return http.StatusForbidden
+	case errors.Is(err, errTooManyTargets):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
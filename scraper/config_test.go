@@ -0,0 +1,327 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/config"
+	"github.com/prometheus/memcached_exporter/health"
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+	"github.com/prometheus/memcached_exporter/keyspace"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+	"github.com/prometheus/memcached_exporter/watch"
+)
+
+func TestApplyConfig(t *testing.T) {
+	newServer := func() *memcachedtest.Server {
+		return memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+			return memcachedtest.Stats{
+				Stats:    map[string]string{"version": "1.6.21", "curr_items": "2", "limit_maxbytes": "1024", "bytes": "512"},
+				Settings: map[string]string{"maxconns": "1024"},
+			}
+		}))
+	}
+	scrape := func(s *Scraper, url string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+	derived := true
+
+	t.Run("Target module", func(t *testing.T) {
+		t.Parallel()
+
+		listed, unlisted := newServer(), newServer()
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		if err := s.ApplyConfig(&config.Config{
+			Modules: map[string]*config.Module{
+				"derived": {Prober: config.ProberDefault, Collectors: config.Collectors{Derived: &derived}},
+			},
+			Targets: []config.Target{{Address: listed.Addr, Module: "derived"}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if body := scrape(s, "/?target="+listed.Addr).Body.String(); !strings.Contains(body, "memcached_memory_utilization_ratio 0.5") {
+			t.Errorf("module of the target was not used. body: %s", body)
+		}
+		if body := scrape(s, "/?target="+unlisted.Addr).Body.String(); strings.Contains(body, "memcached_memory_utilization_ratio") {
+			t.Errorf("unlisted target should use the default module. body: %s", body)
+		}
+		if code := scrape(s, fmt.Sprintf("/?target=%s&module=unknown", listed.Addr)).Code; code != http.StatusBadRequest {
+			t.Errorf("unknown module returned status %d", code)
+		}
+	})
+
+	t.Run("Stream collectors", func(t *testing.T) {
+		t.Parallel()
+
+		srv := newServer()
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		s.SetCollectorOpts(watch.HotKeysOpts{TopK: 10, Window: time.Minute, SampleRate: 1}, watch.EvictionsOpts{}, keyspace.Opts{})
+		if err := s.ApplyConfig(&config.Config{
+			Modules: map[string]*config.Module{
+				"watched": {Prober: config.ProberDefault, Collectors: config.Collectors{HotKeys: true, Evictions: true}},
+			},
+			Targets: []config.Target{{Address: srv.Addr, Module: "watched"}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		body := scrape(s, "/?target="+srv.Addr).Body.String()
+		for _, name := range []string{"memcached_current_items", "memcached_hot_keys_stream_connected", "memcached_item_removals_stream_connected"} {
+			if !strings.Contains(body, name) {
+				t.Errorf("want %s in scrape. body: %s", name, body)
+			}
+		}
+
+		// Reloading without the module stops the collectors.
+		if err := s.ApplyConfig(&config.Config{Targets: []config.Target{{Address: srv.Addr}}}); err != nil {
+			t.Fatal(err)
+		}
+		if body := scrape(s, "/?target="+srv.Addr).Body.String(); strings.Contains(body, "memcached_hot_keys") {
+			t.Errorf("hot keys collector kept after reload. body: %s", body)
+		}
+	})
+
+	t.Run("Const labels", func(t *testing.T) {
+		t.Parallel()
+
+		listed, unlisted := newServer(), newServer()
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		s.SetConstLabels(prometheus.Labels{"pool": "default", "tier": "hot"})
+		if err := s.ApplyConfig(&config.Config{
+			Targets: []config.Target{{Address: listed.Addr, Labels: map[string]string{"pool": "sessions", "shard": "1"}}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if body := scrape(s, "/?target="+listed.Addr).Body.String(); !strings.Contains(body, `memcached_current_items{pool="sessions",shard="1",tier="hot"} 2`) {
+			t.Errorf("target labels were not applied. body: %s", body)
+		}
+		if body := scrape(s, "/?target="+unlisted.Addr).Body.String(); !strings.Contains(body, `memcached_current_items{pool="default",tier="hot"} 2`) {
+			t.Errorf("global labels were not applied. body: %s", body)
+		}
+
+		// The labels apply to the probers and the optional collectors too.
+		probed, metaProbed, watched := newServer(), newServer(), newServer()
+		s.SetCollectorOpts(watch.HotKeysOpts{TopK: 10, Window: time.Minute, SampleRate: 1}, watch.EvictionsOpts{}, keyspace.Opts{})
+		if err := s.ApplyConfig(&config.Config{
+			Modules: map[string]*config.Module{
+				"probed":  {Prober: config.ProberCanary, KeyPrefix: "exporter:"},
+				"meta":    {Prober: config.ProberMeta, KeyPrefix: "exporter:"},
+				"watched": {Prober: config.ProberDefault, Collectors: config.Collectors{HotKeys: true, Evictions: true}},
+			},
+			Targets: []config.Target{
+				{Address: probed.Addr, Module: "probed", Labels: map[string]string{"pool": "sessions"}},
+				{Address: metaProbed.Addr, Module: "meta", Labels: map[string]string{"pool": "sessions"}},
+				{Address: watched.Addr, Module: "watched", Labels: map[string]string{"pool": "sessions"}},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if body := scrape(s, "/?target="+probed.Addr).Body.String(); !strings.Contains(body, `memcached_canary_success{operation="set",pool="sessions",tier="hot"}`) {
+			t.Errorf("labels were not applied to the canary. body: %s", body)
+		}
+		if body := scrape(s, "/?target="+metaProbed.Addr).Body.String(); !strings.Contains(body, `memcached_meta_success{command="mg",pool="sessions",tier="hot"}`) {
+			t.Errorf("labels were not applied to the meta prober. body: %s", body)
+		}
+		body := scrape(s, "/?target="+watched.Addr).Body.String()
+		for _, series := range []string{
+			`memcached_hot_keys_stream_connected{pool="sessions",tier="hot"}`,
+			`memcached_item_removals_stream_connected{pool="sessions",tier="hot"}`,
+		} {
+			if !strings.Contains(body, series) {
+				t.Errorf("want %s in scrape. body: %s", series, body)
+			}
+		}
+
+		err := s.ApplyConfig(&config.Config{
+			Targets: []config.Target{{Address: listed.Addr, Labels: map[string]string{"slab": "1"}}},
+		})
+		if err == nil {
+			t.Error("label clashing with a variable label was accepted")
+		}
+	})
+
+	t.Run("Const labels of collectors", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		s.SetCollectorOpts(watch.HotKeysOpts{}, watch.EvictionsOpts{}, keyspace.Opts{Rules: []*regexp.Regexp{regexp.MustCompile(`^(?P<tenant>[^:]+):`)}})
+		modules := map[string]*config.Module{
+			"evictions": {Prober: config.ProberDefault, Collectors: config.Collectors{Evictions: true}},
+			"keyspace":  {Prober: config.ProberDefault, Collectors: config.Collectors{Keyspace: true}},
+		}
+		for _, tt := range []struct {
+			module, label string
+		}{
+			{"evictions", "reason"},
+			{"keyspace", "tenant"},
+		} {
+			err := s.ApplyConfig(&config.Config{
+				Modules: modules,
+				Targets: []config.Target{{Address: "localhost:11211", Module: tt.module, Labels: map[string]string{tt.label: "x"}}},
+			})
+			if err == nil {
+				t.Errorf("label %q clashing with the %s collector was accepted", tt.label, tt.module)
+			}
+		}
+
+		// Global labels are checked against every module.
+		s.SetConstLabels(prometheus.Labels{"key_prefix": "x"})
+		if err := s.ApplyConfig(&config.Config{Modules: modules}); err == nil {
+			t.Error("global label clashing with the evictions collector was accepted")
+		}
+		if err := s.CheckConstLabels(); err != nil {
+			t.Errorf("label clashing with no enabled collector was rejected: %v", err)
+		}
+	})
+
+	t.Run("Drift baselines", func(t *testing.T) {
+		t.Parallel()
+
+		srv := newServer()
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		s.EnableDrift(exporter.Baselines{"default": {Settings: map[string]string{"maxconns": "1024"}}})
+		if body := scrape(s, "/?target="+srv.Addr).Body.String(); !strings.Contains(body, "memcached_config_drifted_settings 0") {
+			t.Errorf("baselines of the flags were not used. body: %s", body)
+		}
+
+		// The baseline file of the configuration replaces the flags.
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "baselines.yml"), []byte("pools:\n  default:\n    settings:\n      maxconns: 4096\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		filename := filepath.Join(dir, "config.yml")
+		if err := os.WriteFile(filename, []byte("drift:\n  baseline_file: baselines.yml\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c, err := config.Load(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.ApplyConfig(c); err != nil {
+			t.Fatal(err)
+		}
+		if body := scrape(s, "/?target="+srv.Addr).Body.String(); !strings.Contains(body, "memcached_config_drifted_settings 1") {
+			t.Errorf("baselines of the configuration file were not used. body: %s", body)
+		}
+	})
+
+	t.Run("Restrict targets", func(t *testing.T) {
+		t.Parallel()
+
+		listed, unlisted := newServer(), newServer()
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		if err := s.ApplyConfig(&config.Config{
+			Targets: []config.Target{{Address: listed.Addr}},
+			Limits:  config.Limits{RestrictTargets: true},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if code := scrape(s, "/?target="+listed.Addr).Code; code != http.StatusOK {
+			t.Errorf("listed target returned status %d", code)
+		}
+		if code := scrape(s, "/?target="+unlisted.Addr).Code; code != http.StatusForbidden {
+			t.Errorf("unlisted target returned status %d", code)
+		}
+		if code, _ := getStats(t, s, "/api/v1/stats?target="+unlisted.Addr); code != http.StatusForbidden {
+			t.Errorf("stats API of unlisted target returned status %d", code)
+		}
+	})
+
+	t.Run("Max targets", func(t *testing.T) {
+		t.Parallel()
+
+		first, second := newServer(), newServer()
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		if err := s.ApplyConfig(&config.Config{Limits: config.Limits{MaxTargets: 1}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if code := scrape(s, "/?target="+first.Addr).Code; code != http.StatusOK {
+			t.Errorf("first target returned status %d", code)
+		}
+		if code := scrape(s, "/?target="+second.Addr).Code; code != http.StatusServiceUnavailable {
+			t.Errorf("second target returned status %d", code)
+		}
+
+		// Applying a config drops the kept collectors.
+		if err := s.ApplyConfig(&config.Config{Limits: config.Limits{MaxTargets: 1}}); err != nil {
+			t.Fatal(err)
+		}
+		if code := scrape(s, "/?target="+second.Addr).Code; code != http.StatusOK {
+			t.Errorf("second target after reload returned status %d", code)
+		}
+	})
+
+	t.Run("Disabled module", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		err := s.ApplyConfig(&config.Config{
+			Targets: []config.Target{{Address: "localhost:11211", Module: config.ProberCanary}},
+		})
+		if err == nil {
+			t.Error("target with disabled canary module was accepted")
+		}
+	})
+
+	t.Run("Ready targets", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		if targets := s.ReadyTargets(); len(targets) != 0 {
+			t.Errorf("want no ready targets without config, got %v", targets)
+		}
+		if err := s.ApplyConfig(&config.Config{
+			Modules: map[string]*config.Module{
+				"slow": {Prober: config.ProberDefault, Timeout: model.Duration(5 * time.Second)},
+			},
+			Targets: []config.Target{
+				{Address: "a:11211"},
+				{Address: "b:11211", Module: "slow"},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		want := []health.Target{
+			{Address: "a:11211", Timeout: time.Second},
+			{Address: "b:11211", Timeout: 5 * time.Second},
+		}
+		if got := s.ReadyTargets(); !reflect.DeepEqual(got, want) {
+			t.Errorf("want ready targets %v, got %v", want, got)
+		}
+	})
+}
@@ -14,14 +14,29 @@
 package scraper
 
 import (
+	"context"
 	"crypto/tls"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/memcached_exporter/config"
+	"github.com/prometheus/memcached_exporter/keyspace"
 	"github.com/prometheus/memcached_exporter/pkg/exporter"
+	"github.com/prometheus/memcached_exporter/probe"
+	"github.com/prometheus/memcached_exporter/watch"
+)
+
+const (
+	// defaultIdleTimeout is how long long-lived per-target collectors are
+	// kept around after their last scrape.
+	defaultIdleTimeout = 10 * time.Minute
+	// defaultMaxTargets is the maximum number of per-target collectors kept
+	// at a time unless the configuration file sets max_targets.
+	defaultMaxTargets = 1000
 )
 
 type Scraper struct {
@@ -29,16 +44,48 @@ type Scraper struct {
 	timeout   time.Duration
 	tlsConfig *tls.Config
 
+	ctx             context.Context
+	pollOpts        *exporter.PollOpts
+	canaryKeyPrefix string
+	metaKeyPrefix   string
+	derived         bool
+	latency         bool
+	baselines       exporter.Baselines
+	constLabels     prometheus.Labels
+	hotKeysOpts     watch.HotKeysOpts
+	evictionsOpts   watch.EvictionsOpts
+	keyspaceOpts    keyspace.Opts
+
+	mu      sync.Mutex
+	config  *config.Config
+	targets map[targetKey]*cachedCollector
+
 	scrapeCount  prometheus.Counter
 	scrapeErrors prometheus.Counter
 }
 
+type targetKey struct {
+	module string
+	target string
+	pool   string
+}
+
+// cachedCollector is a collector kept across scrapes of the same target.
+type cachedCollector struct {
+	collector    prometheus.Collector
+	cancel       context.CancelFunc
+	ready        chan struct{}
+	lastAccessed time.Time
+}
+
 func New(timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config) *Scraper {
 	logger.Debug("Started scrapper")
 	return &Scraper{
 		logger:    logger,
 		timeout:   timeout,
 		tlsConfig: tlsConfig,
+		ctx:       context.Background(),
+		targets:   map[targetKey]*cachedCollector{},
 		scrapeCount: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "memcached_exporter_scrapes_total",
 			Help: "Count of memcached exporter scapes.",
@@ -53,7 +100,9 @@ func New(timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config) *Scr
 func (s *Scraper) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		target := r.URL.Query().Get("target")
-		s.logger.Debug("scrapping memcached", "target", target)
+		module := r.URL.Query().Get("module")
+		pool := r.URL.Query().Get("pool")
+		s.logger.Debug("scrapping memcached", "target", target, "module", module)
 		s.scrapeCount.Inc()
 
 		if target == "" {
@@ -64,12 +113,208 @@ func (s *Scraper) Handler() http.HandlerFunc {
 			return
 		}
 
-		e := exporter.New(target, s.timeout, s.logger, s.tlsConfig)
+		key, m, err := s.resolve(target, module, pool)
+		var c prometheus.Collector
+		if err == nil {
+			c, err = s.collectorFor(key, m)
+		}
+		if err != nil {
+			s.logger.Warn(err.Error())
+			http.Error(w, err.Error(), statusCode(err))
+			s.scrapeErrors.Inc()
+			return
+		}
+
 		registry := prometheus.NewRegistry()
-		registry.MustRegister(e)
+		if err := registry.Register(c); err != nil {
+			s.logger.Error("Failed to register collectors", "target", target, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			s.scrapeErrors.Inc()
+			return
+		}
 
+		// Exemplars of the latency histograms are only exposed in the
+		// OpenMetrics format.
 		promhttp.HandlerFor(
-			registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError},
+			registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError, EnableOpenMetrics: m.latency},
 		).ServeHTTP(w, r)
 	}
 }
+
+// EnablePolling makes the scraper poll every requested target in the
+// background and answer scrapes from the last successful snapshot. Only
+// targets listed in the configuration file are accepted, and targets which
+// are not scraped for a while stop being polled.
+func (s *Scraper) EnablePolling(ctx context.Context, opts exporter.PollOpts) {
+	s.ctx = ctx
+	s.pollOpts = &opts
+}
+
+// EnableCanary enables the canary module, which writes keys below keyPrefix
+// to the target.
+func (s *Scraper) EnableCanary(keyPrefix string) {
+	s.canaryKeyPrefix = keyPrefix
+}
+
+// EnableMeta enables the meta module, which exercises the meta protocol with
+// keys below keyPrefix on the target.
+func (s *Scraper) EnableMeta(keyPrefix string) {
+	s.metaKeyPrefix = keyPrefix
+}
+
+// EnableDerivedMetrics adds the derived ratio and utilisation metrics to
+// every target.
+func (s *Scraper) EnableDerivedMetrics() {
+	s.derived = true
+}
+
+// EnableLatencyMetrics adds dial, TLS handshake and stats round trip latency
+// histograms of successful and failed attempts to every target.
+func (s *Scraper) EnableLatencyMetrics() {
+	s.latency = true
+}
+
+// SetConstLabels adds labels to every metric of the default module. Labels of
+// targets in the configuration file take precedence.
+func (s *Scraper) SetConstLabels(labels prometheus.Labels) {
+	s.constLabels = labels
+}
+
+// SetCollectorOpts sets the options of the hot keys, evictions and keyspace
+// collectors, which modules of the configuration file can enable.
+func (s *Scraper) SetCollectorOpts(hotKeys watch.HotKeysOpts, evictions watch.EvictionsOpts, keyspaceOpts keyspace.Opts) {
+	s.hotKeysOpts = hotKeys
+	s.evictionsOpts = evictions
+	s.keyspaceOpts = keyspaceOpts
+}
+
+// EnableDrift compares every target against its baseline. The baseline is
+// chosen by the pool parameter, or else by the targets listed in the pools.
+// The baselines of the configuration file take precedence.
+func (s *Scraper) EnableDrift(baselines exporter.Baselines) {
+	s.baselines = baselines
+}
+
+// collectorFor keeps one collector per target and module, so restarts can be
+// detected and the canary latency histograms accumulate between scrapes.
+func (s *Scraper) collectorFor(key targetKey, m module) (prometheus.Collector, error) {
+	return s.cached(key, func(ctx context.Context) prometheus.Collector {
+		cs, start := s.newCollectors(key, m)
+		start(ctx)
+		return cs
+	})
+}
+
+// newCollectors returns the collectors of a scrape of key with module m and
+// a function starting their background work.
+func (s *Scraper) newCollectors(key targetKey, m module) (collectors, func(ctx context.Context)) {
+	switch m.prober {
+	case config.ProberCanary:
+		return collectors{probe.NewCanary(key.target, m.keyPrefix, m.timeout, s.logger, m.tlsConfig, m.constLabels)}, func(context.Context) {}
+	case config.ProberMeta:
+		return collectors{probe.NewMeta(key.target, m.keyPrefix, m.timeout, s.logger, m.tlsConfig, m.constLabels)}, func(context.Context) {}
+	}
+	e := exporter.NewWithOpts(key.target, m.timeout, s.logger, m.tlsConfig, exporter.WithConstLabels(m.constLabels))
+	if m.derived {
+		e.EnableDerivedMetrics()
+	}
+	if m.latency {
+		e.EnableLatencyMetrics()
+	}
+	if m.baseline != nil {
+		e.SetBaseline(m.baseline)
+	}
+
+	cs := collectors{e}
+	var starts []func(ctx context.Context)
+	if s.pollOpts != nil {
+		starts = append(starts, func(ctx context.Context) { e.StartPolling(ctx, *s.pollOpts) })
+	}
+	if m.hotKeys {
+		h := watch.NewHotKeys(key.target, m.timeout, s.logger, m.tlsConfig, s.hotKeysOpts, m.constLabels)
+		cs = append(cs, h)
+		starts = append(starts, h.Start)
+	}
+	if m.evictions {
+		ev := watch.NewEvictions(key.target, m.timeout, s.logger, m.tlsConfig, s.evictionsOpts, m.constLabels)
+		cs = append(cs, ev)
+		starts = append(starts, ev.Start)
+	}
+	if m.keyspace {
+		k := keyspace.New(key.target, m.timeout, s.logger, m.tlsConfig, s.keyspaceOpts, m.constLabels)
+		cs = append(cs, k)
+		starts = append(starts, k.Start)
+	}
+	return cs, func(ctx context.Context) {
+		for _, start := range starts {
+			start(ctx)
+		}
+	}
+}
+
+// collectors combines the collectors of a target.
+type collectors []prometheus.Collector
+
+func (cs collectors) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range cs {
+		c.Describe(ch)
+	}
+}
+
+func (cs collectors) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range cs {
+		c.Collect(ch)
+	}
+}
+
+// cached returns the long-lived collector for key, creating it with
+// newCollector on first use. Collectors which were not requested for a while
+// are dropped and their context is cancelled. New collectors are refused once
+// the maximum number of targets is reached.
+func (s *Scraper) cached(key targetKey, newCollector func(ctx context.Context) prometheus.Collector) (prometheus.Collector, error) {
+	idleTimeout := defaultIdleTimeout
+	if s.pollOpts != nil {
+		idleTimeout = max(10*s.pollOpts.Interval, s.pollOpts.MaxAge)
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	for k, c := range s.targets {
+		if now.Sub(c.lastAccessed) > idleTimeout {
+			s.logger.Debug("dropped idle target", "target", k.target, "module", k.module)
+			c.cancel()
+			delete(s.targets, k)
+		}
+	}
+
+	maxTargets := defaultMaxTargets
+	if s.config != nil && s.config.Limits.MaxTargets > 0 {
+		maxTargets = s.config.Limits.MaxTargets
+	}
+	c, ok := s.targets[key]
+	if !ok && len(s.targets) >= maxTargets {
+		s.mu.Unlock()
+		return nil, errTooManyTargets
+	}
+	if !ok {
+		ctx, cancel := context.WithCancel(s.ctx)
+		c = &cachedCollector{
+			cancel:       cancel,
+			ready:        make(chan struct{}),
+			lastAccessed: now,
+		}
+		s.targets[key] = c
+		s.mu.Unlock()
+
+		// Creating the collector might talk to the target, don't hold the
+		// lock for it.
+		c.collector = newCollector(ctx)
+		close(c.ready)
+		return c.collector, nil
+	}
+	c.lastAccessed = now
+	s.mu.Unlock()
+
+	<-c.ready
+	return c.collector, nil
+}
@@ -14,6 +14,8 @@
 package scraper
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -23,6 +25,10 @@ import (
 	"time"
 
 	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/config"
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
 )
 
 func TestHandler(t *testing.T) {
@@ -80,4 +86,140 @@ func TestHandler(t *testing.T) {
 			t.Errorf("handler returned wrong status code: got %d, want: %d", rr.Code, http.StatusBadRequest)
 		}
 	})
+	t.Run("Polling", func(t *testing.T) {
+		t.Parallel()
+
+		srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+			return memcachedtest.Stats{
+				Stats:    map[string]string{"version": "1.6.21", "cmd_set": "0", "cas_hits": "0", "cas_misses": "0", "cas_badval": "0"},
+				Settings: map[string]string{"maxconns": "1024"},
+			}
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := New(1*time.Second, promslog.NewNopLogger(), nil)
+		s.EnablePolling(ctx, exporter.PollOpts{Interval: time.Minute})
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("/?target=%s", srv.Addr), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("want unlisted target to be refused with 403, got %d", rr.Code)
+		}
+
+		if err := s.ApplyConfig(&config.Config{Targets: []config.Target{{Address: srv.Addr}}}); err != nil {
+			t.Fatal(err)
+		}
+		for range 2 {
+			req, err := http.NewRequest("GET", fmt.Sprintf("/?target=%s", srv.Addr), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rr, req)
+
+			if body := rr.Body.String(); !strings.Contains(body, "memcached_up 1") ||
+				!strings.Contains(body, "memcached_exporter_last_scrape_timestamp_seconds") {
+				t.Errorf("handler did not serve polled metrics. body: %s", body)
+			}
+		}
+		if len(s.targets) != 1 {
+			t.Errorf("want 1 polled target, got %d", len(s.targets))
+		}
+	})
+	t.Run("Canary module", func(t *testing.T) {
+		t.Parallel()
+
+		cache := memcachedtest.NewCache()
+		srv := memcachedtest.NewServer(t, cache.Handler(func(w *bufio.Writer, _ *bufio.Reader, _ string) bool {
+			w.WriteString("ERROR\r\n")
+			return true
+		}))
+
+		s := New(1*time.Second, promslog.NewNopLogger(), nil)
+
+		url := fmt.Sprintf("/?target=%s&module=canary", srv.Addr)
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("disabled canary module returned wrong status code: got %d, want: %d", status, http.StatusBadRequest)
+		}
+
+		s.EnableCanary("test:")
+		for range 2 {
+			rr = httptest.NewRecorder()
+			s.Handler().ServeHTTP(rr, req)
+			if body := rr.Body.String(); !strings.Contains(body, `memcached_canary_success{operation="delete"} 1`) {
+				t.Errorf("handler did not run the canary. body: %s", body)
+			}
+		}
+		if body := rr.Body.String(); !strings.Contains(body, `memcached_canary_duration_seconds_count{operation="get"} 2`) {
+			t.Errorf("canary histograms were not kept across scrapes. body: %s", body)
+		}
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		t.Parallel()
+
+		srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+			return memcachedtest.Stats{Stats: map[string]string{"version": "1.6.21"}}
+		}))
+
+		s := New(1*time.Second, promslog.NewNopLogger(), nil)
+		s.EnableLatencyMetrics()
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("/?target=%s", srv.Addr), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+		rr := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rr, req)
+		want := fmt.Sprintf(`target=%q} `, srv.Addr)
+		if body := rr.Body.String(); !strings.Contains(body, `memcached_exporter_request_duration_seconds_count{operation="dial",result="success"} 1`) || !strings.Contains(body, want) {
+			t.Errorf("latency exemplars missing. body: %s", body)
+		}
+	})
+
+	t.Run("Drift pool", func(t *testing.T) {
+		t.Parallel()
+
+		srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+			return memcachedtest.Stats{
+				Stats:    map[string]string{"version": "1.6.21"},
+				Settings: map[string]string{"maxconns": "1024"},
+			}
+		}))
+
+		s := New(1*time.Second, promslog.NewNopLogger(), nil)
+		s.EnableDrift(exporter.Baselines{
+			"default":  {Settings: map[string]string{"maxconns": "1024"}},
+			"sessions": {Settings: map[string]string{"maxconns": "4096"}},
+		})
+
+		for pool, want := range map[string]string{
+			"":         "memcached_config_drifted_settings 0",
+			"sessions": "memcached_config_drifted_settings 1",
+		} {
+			req, err := http.NewRequest("GET", fmt.Sprintf("/?target=%s&pool=%s", srv.Addr, pool), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rr, req)
+			if body := rr.Body.String(); !strings.Contains(body, want) {
+				t.Errorf("pool %q: want %q in body: %s", pool, want, body)
+			}
+		}
+	})
 }
@@ -0,0 +1,131 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/memcached_exporter/client"
+)
+
+var (
+	// statsSections are the stats commands the stats API runs, "stats"
+	// being the plain stats command.
+	statsSections   = []string{"stats", "items", "slabs", "settings", "sizes", "conns"}
+	defaultSections = []string{"stats", "items", "slabs", "settings"}
+)
+
+// statsResponse is the JSON body returned by the stats API.
+type statsResponse struct {
+	Target   string                   `json:"target"`
+	Error    string                   `json:"error,omitempty"`
+	Sections map[string]*statsSection `json:"sections,omitempty"`
+}
+
+type statsSection struct {
+	DurationSeconds float64           `json:"duration_seconds"`
+	Error           string            `json:"error,omitempty"`
+	Values          map[string]string `json:"values,omitempty"`
+}
+
+// StatsHandler returns the raw values of the requested stats sections of a
+// target as JSON, using the same module, timeout and TLS configuration as
+// scrapes.
+// A failing section is reported with its error next to the other sections.
+func (s *Scraper) StatsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		if target == "" {
+			writeStatsError(w, http.StatusBadRequest, statsResponse{Error: "'target' parameter must be specified"})
+			return
+		}
+
+		_, m, err := s.resolve(target, r.URL.Query().Get("module"), "")
+		if err != nil {
+			writeStatsError(w, statusCode(err), statsResponse{Target: target, Error: err.Error()})
+			return
+		}
+
+		sections := defaultSections
+		if v := r.URL.Query().Get("sections"); v != "" {
+			sections = strings.Split(v, ",")
+		}
+		for _, section := range sections {
+			if !slices.Contains(statsSections, section) {
+				writeStatsError(w, http.StatusBadRequest, statsResponse{
+					Target: target,
+					Error:  fmt.Sprintf("unknown section %q, must be one of %s", section, strings.Join(statsSections, ",")),
+				})
+				return
+			}
+		}
+
+		resp := statsResponse{Target: target, Sections: map[string]*statsSection{}}
+		var conn *client.Conn
+		defer func() {
+			if conn != nil {
+				conn.Close()
+			}
+		}()
+		for _, section := range sections {
+			start := time.Now()
+			var (
+				values map[string]string
+				err    error
+			)
+			if conn == nil {
+				conn, err = client.Dial(target, m.timeout, m.tlsConfig)
+			}
+			if err == nil {
+				values, err = conn.Stats(statsArgs(section)...)
+			}
+			result := &statsSection{DurationSeconds: time.Since(start).Seconds(), Values: values}
+			if err != nil {
+				s.logger.Debug("Failed to query stats section", "target", target, "section", section, "err", err)
+				result.Error = err.Error()
+				// Anything but an error response leaves the connection in an
+				// unknown state, reconnect for the next section.
+				if conn != nil && !errors.Is(err, client.ErrServer) {
+					conn.Close()
+					conn = nil
+				}
+			}
+			resp.Sections[section] = result
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			s.logger.Error("Failed to write stats response", "err", err)
+		}
+	}
+}
+
+func statsArgs(section string) []string {
+	if section == "stats" {
+		return nil
+	}
+	return []string{section}
+}
+
+func writeStatsError(w http.ResponseWriter, code int, resp statsResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
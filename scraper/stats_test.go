@@ -0,0 +1,108 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+func getStats(t *testing.T, s *Scraper, url string) (int, statsResponse) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	s.StatsHandler().ServeHTTP(rr, req)
+
+	var resp statsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rr.Body.String(), err)
+	}
+	return rr.Code, resp
+}
+
+func TestStatsHandler(t *testing.T) {
+	srv := memcachedtest.NewServer(t, memcachedtest.StatsHandler(func() memcachedtest.Stats {
+		return memcachedtest.Stats{
+			Stats: map[string]string{"version": "1.6.21", "curr_items": "2"},
+			Slabs: map[string]string{"1:chunk_size": "96", "active_slabs": "1"},
+		}
+	}))
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		code, resp := getStats(t, s, fmt.Sprintf("/api/v1/stats?target=%s&sections=stats,slabs,conns", srv.Addr))
+		if code != http.StatusOK {
+			t.Fatalf("want status 200, got %d", code)
+		}
+		if len(resp.Sections) != 3 {
+			t.Fatalf("want 3 sections, got %v", resp.Sections)
+		}
+		if v := resp.Sections["stats"].Values["curr_items"]; v != "2" {
+			t.Errorf("want curr_items 2, got %q", v)
+		}
+		if v := resp.Sections["slabs"].Values["1:chunk_size"]; v != "96" {
+			t.Errorf("want chunk size 96, got %q", v)
+		}
+		if resp.Sections["conns"].Error == "" {
+			t.Error("want an error for the unsupported conns section")
+		}
+	})
+
+	t.Run("Unknown section", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		code, resp := getStats(t, s, fmt.Sprintf("/api/v1/stats?target=%s&sections=stats,detail", srv.Addr))
+		if code != http.StatusBadRequest || resp.Error == "" {
+			t.Errorf("want status 400 with an error, got %d %+v", code, resp)
+		}
+	})
+
+	t.Run("No target", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(time.Second, promslog.NewNopLogger(), nil)
+		if code, _ := getStats(t, s, "/api/v1/stats"); code != http.StatusBadRequest {
+			t.Errorf("want status 400, got %d", code)
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(100*time.Millisecond, promslog.NewNopLogger(), nil)
+		code, resp := getStats(t, s, "/api/v1/stats?target=127.0.0.1:1")
+		if code != http.StatusOK {
+			t.Fatalf("want status 200, got %d", code)
+		}
+		for _, section := range defaultSections {
+			if resp.Sections[section] == nil || resp.Sections[section].Error == "" {
+				t.Errorf("want an error for section %s, got %+v", section, resp.Sections[section])
+			}
+		}
+	})
+}
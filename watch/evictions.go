@@ -0,0 +1,190 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+const (
+	subsystemRemovals = "item_removals"
+
+	// otherPrefix is reported for keys beyond the key prefix limit.
+	otherPrefix = "_other"
+)
+
+// removalBuckets spans one second to roughly seven weeks.
+var removalBuckets = prometheus.ExponentialBuckets(1, 4, 12)
+
+// removalReasons maps log entry types to the exported reason label.
+var removalReasons = map[string]string{
+	"eviction": "evicted",
+	"deleted":  "deleted",
+}
+
+// EvictionsOpts configures eviction analytics.
+type EvictionsOpts struct {
+	// Deletions also follows the deletions log stream.
+	Deletions bool
+	// MaxPrefixes bounds the number of distinct key prefixes exported. Keys
+	// with further prefixes are counted as "_other". Zero means no limit.
+	MaxPrefixes int
+	// KeyOpts controls how keys are exported.
+	KeyOpts
+}
+
+// Evictions follows the evictions, and optionally deletions, log streams of
+// a memcached server and exports what kind of items are removed. It
+// implements prometheus.Collector.
+type Evictions struct {
+	opts   EvictionsOpts
+	stream *stream
+
+	mu       sync.Mutex
+	prefixes map[string]struct{}
+
+	items *prometheus.CounterVec
+	slabs *prometheus.CounterVec
+	ttl   *prometheus.HistogramVec
+	idle  *prometheus.HistogramVec
+}
+
+// NewEvictions returns an initialized eviction collector. Start must be called
+// to subscribe to the log stream. The optional constLabels are added to every
+// metric.
+func NewEvictions(address string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config, opts EvictionsOpts, constLabels prometheus.Labels) *Evictions {
+	e := &Evictions{
+		opts:     opts,
+		prefixes: map[string]struct{}{},
+		items: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystemRemovals,
+			Name:        "total",
+			Help:        "Total number of items evicted or deleted, by key prefix and whether they were fetched since being stored.",
+			ConstLabels: constLabels,
+		}, []string{"reason", "key_prefix", "fetched"}),
+		slabs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystemRemovals,
+			Name:        "by_slab_total",
+			Help:        "Total number of items evicted or deleted per slab class.",
+			ConstLabels: constLabels,
+		}, []string{"reason", "slab"}),
+		ttl: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystemRemovals,
+			Name:        "ttl_remaining_seconds",
+			Help:        "Remaining time to live of evicted or deleted items. Items without an expiry are not observed.",
+			Buckets:     removalBuckets,
+			ConstLabels: constLabels,
+		}, []string{"reason"}),
+		idle: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystemRemovals,
+			Name:        "idle_seconds",
+			Help:        "Time since evicted or deleted items were last accessed.",
+			Buckets:     removalBuckets,
+			ConstLabels: constLabels,
+		}, []string{"reason"}),
+	}
+	streams := []string{"evictions"}
+	if opts.Deletions {
+		streams = append(streams, "deletions")
+	}
+	e.stream = newStream(subsystemRemovals, address, timeout, logger, tlsConfig, streams, e.handle, constLabels)
+	return e
+}
+
+// Start subscribes to the log stream until ctx is cancelled.
+func (e *Evictions) Start(ctx context.Context) {
+	go e.stream.run(ctx)
+}
+
+func (e *Evictions) handle(en entry) {
+	reason, ok := removalReasons[en["type"]]
+	if !ok {
+		return
+	}
+
+	fetched := "unknown"
+	switch en["fetch"] {
+	case "yes":
+		fetched = "true"
+	case "no":
+		fetched = "false"
+	}
+	e.items.WithLabelValues(reason, e.prefix(en.key()), fetched).Inc()
+
+	// Older memcached versions log the slab class as cls.
+	slab, ok := en["clsid"]
+	if !ok {
+		slab, ok = en["cls"]
+	}
+	if ok {
+		e.slabs.WithLabelValues(reason, slab).Inc()
+	}
+	// A TTL of -1 marks items without an expiry.
+	if ttl, ok := en.int("ttl"); ok && ttl >= 0 {
+		e.ttl.WithLabelValues(reason).Observe(float64(ttl))
+	}
+	if la, ok := en.int("la"); ok && la >= 0 {
+		e.idle.WithLabelValues(reason).Observe(float64(la))
+	}
+}
+
+// prefix maps key to its label value, bounding the number of distinct
+// prefixes.
+func (e *Evictions) prefix(key string) string {
+	p := e.opts.label(key)
+	if e.opts.MaxPrefixes <= 0 {
+		return p
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.prefixes[p]; ok {
+		return p
+	}
+	if len(e.prefixes) >= e.opts.MaxPrefixes {
+		return otherPrefix
+	}
+	e.prefixes[p] = struct{}{}
+	return p
+}
+
+// Describe describes all the metrics exported by the eviction collector. It
+// implements prometheus.Collector.
+func (e *Evictions) Describe(ch chan<- *prometheus.Desc) {
+	e.items.Describe(ch)
+	e.slabs.Describe(ch)
+	e.ttl.Describe(ch)
+	e.idle.Describe(ch)
+	e.stream.describe(ch)
+}
+
+// Collect delivers the eviction metrics. It implements prometheus.Collector.
+func (e *Evictions) Collect(ch chan<- prometheus.Metric) {
+	e.items.Collect(ch)
+	e.slabs.Collect(ch)
+	e.ttl.Collect(ch)
+	e.idle.Collect(ch)
+	e.stream.collect(ch)
+}
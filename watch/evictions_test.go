@@ -0,0 +1,116 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+)
+
+func TestEvictions(t *testing.T) {
+	srv := logServer(t, "watch evictions deletions", []string{
+		"ts=1700000000.1 gid=1 type=eviction key=user%3A1 fetch=yes ttl=30 la=120 clsid=1",
+		"ts=1700000000.2 gid=2 type=eviction key=user%3A2 fetch=no ttl=-1 la=3000 clsid=1",
+		"ts=1700000000.3 gid=3 type=eviction key=session%3A1 fetch=no ttl=2 la=5 clsid=2",
+		"ts=1700000000.4 gid=4 type=eviction key=cart%3A1 fetch=no ttl=2 la=5 clsid=2",
+		"ts=1700000000.5 gid=5 type=deleted key=user%3A3 cmd=delete clsid=1 size=80",
+		"ts=1700000000.6 gid=6 type=item_get key=user%3A3 status=found clsid=1 cfd=20",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := NewEvictions(srv.Addr, time.Second, promslog.NewNopLogger(), nil, EvictionsOpts{
+		Deletions:   true,
+		MaxPrefixes: 2,
+		KeyOpts: KeyOpts{
+			PrefixDelimiter: ":",
+			PrefixDepth:     1,
+		},
+	}, nil)
+	e.Start(ctx)
+	waitForLines(t, e.stream, 6)
+
+	want := `
+# HELP memcached_item_removals_by_slab_total Total number of items evicted or deleted per slab class.
+# TYPE memcached_item_removals_by_slab_total counter
+memcached_item_removals_by_slab_total{reason="deleted",slab="1"} 1
+memcached_item_removals_by_slab_total{reason="evicted",slab="1"} 2
+memcached_item_removals_by_slab_total{reason="evicted",slab="2"} 2
+# HELP memcached_item_removals_total Total number of items evicted or deleted, by key prefix and whether they were fetched since being stored.
+# TYPE memcached_item_removals_total counter
+memcached_item_removals_total{fetched="false",key_prefix="_other",reason="evicted"} 1
+memcached_item_removals_total{fetched="false",key_prefix="session",reason="evicted"} 1
+memcached_item_removals_total{fetched="false",key_prefix="user",reason="evicted"} 1
+memcached_item_removals_total{fetched="true",key_prefix="user",reason="evicted"} 1
+memcached_item_removals_total{fetched="unknown",key_prefix="user",reason="deleted"} 1
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want),
+		"memcached_item_removals_by_slab_total", "memcached_item_removals_total"); err != nil {
+		t.Error(err)
+	}
+
+	want = `
+# HELP memcached_item_removals_ttl_remaining_seconds Remaining time to live of evicted or deleted items. Items without an expiry are not observed.
+# TYPE memcached_item_removals_ttl_remaining_seconds histogram
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="1"} 0
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="4"} 2
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="16"} 2
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="64"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="256"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="1024"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="4096"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="16384"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="65536"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="262144"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="1.048576e+06"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="4.194304e+06"} 3
+memcached_item_removals_ttl_remaining_seconds_bucket{reason="evicted",le="+Inf"} 3
+memcached_item_removals_ttl_remaining_seconds_sum{reason="evicted"} 34
+memcached_item_removals_ttl_remaining_seconds_count{reason="evicted"} 3
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), "memcached_item_removals_ttl_remaining_seconds"); err != nil {
+		t.Error(err)
+	}
+	if got := testutil.ToFloat64(e.stream.lines); got != 6 {
+		t.Errorf("want 6 log lines, got %v", got)
+	}
+}
+
+func TestEvictionsInvalidUTF8(t *testing.T) {
+	srv := logServer(t, "watch evictions", []string{
+		"ts=1700000000.1 gid=1 type=eviction key=%FFabc fetch=no ttl=30 la=120 clsid=1",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := NewEvictions(srv.Addr, time.Second, promslog.NewNopLogger(), nil, EvictionsOpts{}, nil)
+	e.Start(ctx)
+	waitForLines(t, e.stream, 1)
+
+	want := `
+# HELP memcached_item_removals_total Total number of items evicted or deleted, by key prefix and whether they were fetched since being stored.
+# TYPE memcached_item_removals_total counter
+memcached_item_removals_total{fetched="false",key_prefix="` + "\uFFFD" + `abc",reason="evicted"} 1
+`
+	if err := testutil.CollectAndCompare(e, strings.NewReader(want), "memcached_item_removals_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+const (
+	subsystemHotKeys = "hot_keys"
+
+	// windowSlices is the number of slices the sliding window is made of.
+	windowSlices = 6
+)
+
+// HotKeysOpts configures hot key detection.
+type HotKeysOpts struct {
+	// TopK is the number of hottest keys exported.
+	TopK int
+	// Window is the length of the sliding window keys are counted over.
+	Window time.Duration
+	// SampleRate is the fraction of log lines which are counted.
+	SampleRate float64
+	// Mutations also counts stores, not only fetches.
+	Mutations bool
+	// KeyOpts controls how keys are exported.
+	KeyOpts
+}
+
+// HotKeys follows the fetchers, and optionally mutations, log streams of a
+// memcached server and exports the most frequently accessed keys. It
+// implements prometheus.Collector.
+type HotKeys struct {
+	opts   HotKeysOpts
+	stream *stream
+
+	mu      sync.Mutex
+	topK    *topK
+	started time.Time
+
+	hotKeys *prometheus.Desc
+}
+
+// NewHotKeys returns an initialized hot key collector. Start must be called
+// to subscribe to the log stream. The optional constLabels are added to every
+// metric.
+func NewHotKeys(address string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config, opts HotKeysOpts, constLabels prometheus.Labels) *HotKeys {
+	h := &HotKeys{
+		opts: opts,
+		topK: newTopK(opts.TopK, windowSlices),
+		hotKeys: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, "", "hot_keys"),
+			"Estimated accesses per second of the most frequently accessed keys or key prefixes over the sliding window.",
+			[]string{"key_prefix"},
+			constLabels,
+		),
+	}
+	streams := []string{"fetchers"}
+	if opts.Mutations {
+		streams = append(streams, "mutations")
+	}
+	h.stream = newStream(subsystemHotKeys, address, timeout, logger, tlsConfig, streams, h.handle, constLabels)
+	return h
+}
+
+// Start subscribes to the log stream until ctx is cancelled.
+func (h *HotKeys) Start(ctx context.Context) {
+	h.mu.Lock()
+	h.started = time.Now()
+	h.mu.Unlock()
+
+	go h.stream.run(ctx)
+	go func() {
+		ticker := time.NewTicker(h.opts.Window / windowSlices)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.mu.Lock()
+				h.topK.rotate()
+				h.mu.Unlock()
+			}
+		}
+	}()
+}
+
+func (h *HotKeys) handle(e entry) {
+	switch e["type"] {
+	case "item_get", "item_store":
+	default:
+		return
+	}
+	if h.opts.SampleRate < 1 && rand.Float64() >= h.opts.SampleRate {
+		return
+	}
+	key := h.opts.label(e.key())
+	if key == "" {
+		return
+	}
+
+	h.mu.Lock()
+	h.topK.add(key)
+	h.mu.Unlock()
+}
+
+// Describe describes all the metrics exported by the hot key collector. It
+// implements prometheus.Collector.
+func (h *HotKeys) Describe(ch chan<- *prometheus.Desc) {
+	ch <- h.hotKeys
+	h.stream.describe(ch)
+}
+
+// Collect delivers the current hottest keys. It implements
+// prometheus.Collector.
+func (h *HotKeys) Collect(ch chan<- prometheus.Metric) {
+	h.mu.Lock()
+	top := h.topK.top()
+	window := min(time.Since(h.started), h.opts.Window).Seconds()
+	h.mu.Unlock()
+
+	if window > 0 {
+		// Truncation and hashing can map several keys to the same label.
+		rates := map[string]float64{}
+		for _, c := range top {
+			rates[c.key] += float64(c.count) / h.opts.SampleRate / window
+		}
+		for k, v := range rates {
+			ch <- prometheus.MustNewConstMetric(h.hotKeys, prometheus.GaugeValue, v, k)
+		}
+	}
+	h.stream.collect(ch)
+}
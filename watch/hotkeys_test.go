@@ -0,0 +1,141 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+
+	"github.com/prometheus/memcached_exporter/internal/memcachedtest"
+)
+
+// logServer answers the watch command with OK followed by lines.
+func logServer(t *testing.T, want string, lines []string) *memcachedtest.Server {
+	return memcachedtest.NewServer(t, func(w *bufio.Writer, _ *bufio.Reader, line string) bool {
+		if line != want {
+			w.WriteString("ERROR\r\n")
+			return true
+		}
+		w.WriteString("OK\r\n")
+		for _, l := range lines {
+			w.WriteString(l + "\r\n")
+		}
+		return true
+	})
+}
+
+func waitForLines(t *testing.T, s *stream, n float64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(s.lines) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v log lines", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHotKeys(t *testing.T) {
+	var lines []string
+	for i := range 10 {
+		lines = append(lines,
+			fmt.Sprintf("ts=1700000000.%d gid=%d type=item_get key=user%%3A1%%3Aprofile status=found clsid=1 cfd=20", i, 3*i),
+			fmt.Sprintf("ts=1700000000.%d gid=%d type=item_get key=session:%d:data status=not_found clsid=1 cfd=20", i, 3*i+1, i),
+			fmt.Sprintf("ts=1700000000.%d gid=%d type=item_store key=user:2:profile status=stored cmd=set ttl=0 clsid=1 cfd=20", i, 3*i+2),
+		)
+	}
+	lines = append(lines, "skipped=7")
+	srv := logServer(t, "watch fetchers", lines)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHotKeys(srv.Addr, time.Second, promslog.NewNopLogger(), nil, HotKeysOpts{
+		TopK:       2,
+		Window:     time.Hour,
+		SampleRate: 1,
+		KeyOpts: KeyOpts{
+			PrefixDelimiter: ":",
+			PrefixDepth:     2,
+		},
+	}, nil)
+	h.Start(ctx)
+	waitForLines(t, h.stream, 30)
+
+	got := map[string]bool{}
+	h.mu.Lock()
+	for _, c := range h.topK.top() {
+		got[c.key] = true
+	}
+	h.mu.Unlock()
+	if len(got) != 2 || !got["user:1"] {
+		t.Errorf("unexpected hot keys %v", got)
+	}
+
+	want := `
+# HELP memcached_hot_keys_log_lines_skipped_total Total number of log lines memcached dropped because the exporter did not keep up.
+# TYPE memcached_hot_keys_log_lines_skipped_total counter
+memcached_hot_keys_log_lines_skipped_total 7
+# HELP memcached_hot_keys_stream_connected Whether the exporter is currently subscribed to the memcached log stream.
+# TYPE memcached_hot_keys_stream_connected gauge
+memcached_hot_keys_stream_connected 1
+`
+	if err := testutil.CollectAndCompare(h, strings.NewReader(want),
+		"memcached_hot_keys_log_lines_skipped_total", "memcached_hot_keys_stream_connected"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(h, "memcached_hot_keys"); n != 2 {
+		t.Errorf("want 2 hot keys, got %d", n)
+	}
+}
+
+func TestHotKeysInvalidUTF8(t *testing.T) {
+	srv := logServer(t, "watch fetchers", []string{
+		"ts=1700000000.1 gid=1 type=item_get key=%FFabc status=found clsid=1 cfd=20",
+		"ts=1700000000.2 gid=2 type=item_get key=gr%C3%BC%C3%9Fe status=found clsid=1 cfd=20",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHotKeys(srv.Addr, time.Second, promslog.NewNopLogger(), nil, HotKeysOpts{
+		TopK:       10,
+		Window:     time.Hour,
+		SampleRate: 1,
+		KeyOpts:    KeyOpts{MaxKeyLength: 4},
+	}, nil)
+	h.Start(ctx)
+	waitForLines(t, h.stream, 2)
+
+	// Collecting panics on label values which are not valid UTF-8.
+	if n := testutil.CollectAndCount(h, "memcached_hot_keys"); n != 2 {
+		t.Errorf("want 2 hot keys, got %d", n)
+	}
+	got := map[string]bool{}
+	h.mu.Lock()
+	for _, c := range h.topK.top() {
+		got[c.key] = true
+	}
+	h.mu.Unlock()
+	if !got["\uFFFDabc"] || !got["grüß"] {
+		t.Errorf("unexpected hot keys %v", got)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+)
+
+// KeyOpts controls how item keys are turned into label values.
+type KeyOpts struct {
+	// PrefixDelimiter and PrefixDepth aggregate keys by their first
+	// PrefixDepth delimited segments. An empty delimiter keeps full keys.
+	PrefixDelimiter string
+	PrefixDepth     int
+	// MaxKeyLength truncates exported keys to this many characters. Zero
+	// disables truncation.
+	MaxKeyLength int
+	// HashKeys exports a hash instead of the key itself.
+	HashKeys bool
+}
+
+// label maps a key to the exported label value.
+func (o KeyOpts) label(key string) string {
+	if o.PrefixDelimiter != "" && o.PrefixDepth > 0 {
+		parts := strings.SplitN(key, o.PrefixDelimiter, o.PrefixDepth+1)
+		if len(parts) > o.PrefixDepth {
+			key = strings.Join(parts[:o.PrefixDepth], o.PrefixDelimiter)
+		}
+	}
+	if o.HashKeys {
+		sum := sha256.Sum256([]byte(key))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	}
+	if o.MaxKeyLength > 0 && utf8.RuneCountInString(key) > o.MaxKeyLength {
+		key = string([]rune(key)[:o.MaxKeyLength])
+	}
+	return key
+}
@@ -0,0 +1,35 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import "testing"
+
+func TestKeyOptsLabel(t *testing.T) {
+	for _, tc := range []struct {
+		opts KeyOpts
+		key  string
+		want string
+	}{
+		{KeyOpts{}, "user:1:profile", "user:1:profile"},
+		{KeyOpts{PrefixDelimiter: ":", PrefixDepth: 1}, "user:1:profile", "user"},
+		{KeyOpts{PrefixDelimiter: ":", PrefixDepth: 5}, "user:1:profile", "user:1:profile"},
+		{KeyOpts{MaxKeyLength: 4}, "user:1:profile", "user"},
+		{KeyOpts{MaxKeyLength: 4}, "grüße:1", "grüß"},
+		{KeyOpts{HashKeys: true}, "secret", "sha256:2bb80d537b1da3e3"},
+	} {
+		if got := tc.opts.label(tc.key); got != tc.want {
+			t.Errorf("label(%q) with %+v = %q, want %q", tc.key, tc.opts, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watch implements collectors analysing the log streams memcached
+// offers through the "watch" command.
+package watch
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/memcached_exporter/client"
+	"github.com/prometheus/memcached_exporter/pkg/exporter"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// entry is a parsed log line, e.g.
+// "ts=1700000000.123 gid=5 type=item_get key=foo status=found clsid=1 cfd=20".
+type entry map[string]string
+
+func parseEntry(line string) entry {
+	e := entry{}
+	for _, field := range strings.Fields(line) {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		e[k] = v
+	}
+	return e
+}
+
+// key returns the logged item key. memcached URI encodes keys in log lines.
+// Keys are binary safe, so bytes which are not valid UTF-8 are replaced to
+// keep the key usable as a label value.
+func (e entry) key() string {
+	k := e["key"]
+	if u, err := url.PathUnescape(k); err == nil {
+		k = u
+	}
+	return strings.ToValidUTF8(k, "\uFFFD")
+}
+
+func (e entry) int(name string) (int64, bool) {
+	v, err := strconv.ParseInt(e[name], 10, 64)
+	return v, err == nil
+}
+
+// stream subscribes to memcached log streams and hands every log entry to
+// handle, reconnecting until its context is cancelled.
+type stream struct {
+	address   string
+	timeout   time.Duration
+	tlsConfig *tls.Config
+	logger    *slog.Logger
+	streams   []string
+	handle    func(entry)
+
+	connected atomic.Bool
+
+	connectedDesc *prometheus.Desc
+	lines         prometheus.Counter
+	skipped       prometheus.Counter
+}
+
+func newStream(subsystem, address string, timeout time.Duration, logger *slog.Logger, tlsConfig *tls.Config, streams []string, handle func(entry), constLabels prometheus.Labels) *stream {
+	return &stream{
+		address:   address,
+		timeout:   timeout,
+		tlsConfig: tlsConfig,
+		logger:    logger,
+		streams:   streams,
+		handle:    handle,
+		connectedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(exporter.Namespace, subsystem, "stream_connected"),
+			"Whether the exporter is currently subscribed to the memcached log stream.",
+			nil, constLabels,
+		),
+		lines: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystem,
+			Name:        "log_lines_total",
+			Help:        "Total number of log lines read from the memcached log stream.",
+			ConstLabels: constLabels,
+		}),
+		skipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   exporter.Namespace,
+			Subsystem:   subsystem,
+			Name:        "log_lines_skipped_total",
+			Help:        "Total number of log lines memcached dropped because the exporter did not keep up.",
+			ConstLabels: constLabels,
+		}),
+	}
+}
+
+func (s *stream) describe(ch chan<- *prometheus.Desc) {
+	ch <- s.connectedDesc
+	s.lines.Describe(ch)
+	s.skipped.Describe(ch)
+}
+
+func (s *stream) collect(ch chan<- prometheus.Metric) {
+	v := 0.
+	if s.connected.Load() {
+		v = 1
+	}
+	ch <- prometheus.MustNewConstMetric(s.connectedDesc, prometheus.GaugeValue, v)
+	s.lines.Collect(ch)
+	s.skipped.Collect(ch)
+}
+
+func (s *stream) run(ctx context.Context) {
+	backoff := minBackoff
+	for {
+		start := time.Now()
+		err := s.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Error("Memcached log stream failed", "streams", s.streams, "err", err)
+
+		if time.Since(start) > maxBackoff {
+			backoff = minBackoff
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+func (s *stream) watch(ctx context.Context) error {
+	conn, err := client.Dial(s.address, s.timeout, s.tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := conn.Command("watch " + strings.Join(s.streams, " "))
+	if err != nil {
+		return err
+	}
+	if resp != "OK" {
+		return fmt.Errorf("unexpected response %q to watch", resp)
+	}
+
+	// Log streams are idle while memcached has nothing to report, so only
+	// cancellation ends the read loop.
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	s.connected.Store(true)
+	defer s.connected.Store(false)
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return err
+		}
+		if n, ok := strings.CutPrefix(line, "skipped="); ok {
+			if v, err := strconv.ParseFloat(n, 64); err == nil {
+				s.skipped.Add(v)
+			}
+			continue
+		}
+		s.lines.Inc()
+		s.handle(parseEntry(line))
+	}
+}
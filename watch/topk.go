@@ -0,0 +1,182 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"container/heap"
+	"hash/maphash"
+	"slices"
+)
+
+const (
+	sketchDepth = 4
+	sketchWidth = 2048
+)
+
+// countMinSketch estimates the frequency of keys in bounded memory. Estimates
+// are never lower than the true count.
+type countMinSketch struct {
+	seeds  [sketchDepth]maphash.Seed
+	counts [sketchDepth][sketchWidth]uint64
+}
+
+func newCountMinSketch(seeds [sketchDepth]maphash.Seed) *countMinSketch {
+	return &countMinSketch{seeds: seeds}
+}
+
+func (s *countMinSketch) add(key string) {
+	for i := range s.counts {
+		s.counts[i][maphash.String(s.seeds[i], key)%sketchWidth]++
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint64 {
+	var m uint64
+	for i := range s.counts {
+		c := s.counts[i][maphash.String(s.seeds[i], key)%sketchWidth]
+		if i == 0 || c < m {
+			m = c
+		}
+	}
+	return m
+}
+
+func (s *countMinSketch) reset() {
+	s.counts = [sketchDepth][sketchWidth]uint64{}
+}
+
+type candidate struct {
+	key   string
+	count uint64
+	index int
+}
+
+// candidates is a min-heap of the current top keys by count.
+type candidates []*candidate
+
+func (c candidates) Len() int           { return len(c) }
+func (c candidates) Less(i, j int) bool { return c[i].count < c[j].count }
+func (c candidates) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+	c[i].index = i
+	c[j].index = j
+}
+
+func (c *candidates) Push(x any) {
+	e := x.(*candidate)
+	e.index = len(*c)
+	*c = append(*c, e)
+}
+
+func (c *candidates) Pop() any {
+	old := *c
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*c = old[:len(old)-1]
+	return e
+}
+
+// topK tracks the k most frequent keys over a sliding window made of a ring
+// of n count-min sketches, one per window slice. It is not safe for
+// concurrent use.
+type topK struct {
+	k        int
+	sketches []*countMinSketch
+	current  int
+
+	heap  candidates
+	byKey map[string]*candidate
+}
+
+func newTopK(k, n int) *topK {
+	var seeds [sketchDepth]maphash.Seed
+	for i := range seeds {
+		seeds[i] = maphash.MakeSeed()
+	}
+	t := &topK{k: k, byKey: map[string]*candidate{}}
+	for range n {
+		t.sketches = append(t.sketches, newCountMinSketch(seeds))
+	}
+	return t
+}
+
+func (t *topK) estimate(key string) uint64 {
+	var n uint64
+	for _, s := range t.sketches {
+		n += s.estimate(key)
+	}
+	return n
+}
+
+func (t *topK) add(key string) {
+	t.sketches[t.current].add(key)
+	n := t.estimate(key)
+
+	if c, ok := t.byKey[key]; ok {
+		c.count = n
+		heap.Fix(&t.heap, c.index)
+		return
+	}
+	if len(t.heap) < t.k {
+		c := &candidate{key: key, count: n}
+		heap.Push(&t.heap, c)
+		t.byKey[key] = c
+		return
+	}
+	if least := t.heap[0]; n > least.count {
+		delete(t.byKey, least.key)
+		least.key, least.count = key, n
+		t.byKey[key] = least
+		heap.Fix(&t.heap, 0)
+	}
+}
+
+// rotate starts a new window slice, forgetting the oldest one.
+func (t *topK) rotate() {
+	t.current = (t.current + 1) % len(t.sketches)
+	t.sketches[t.current].reset()
+
+	kept := t.heap[:0]
+	for _, c := range t.heap {
+		c.count = t.estimate(c.key)
+		if c.count == 0 {
+			delete(t.byKey, c.key)
+			continue
+		}
+		kept = append(kept, c)
+	}
+	t.heap = kept
+	for i, c := range t.heap {
+		c.index = i
+	}
+	heap.Init(&t.heap)
+}
+
+// top returns the tracked keys ordered by descending count.
+func (t *topK) top() []candidate {
+	r := make([]candidate, 0, len(t.heap))
+	for _, c := range t.heap {
+		r = append(r, *c)
+	}
+	slices.SortFunc(r, func(a, b candidate) int {
+		switch {
+		case a.count > b.count:
+			return -1
+		case a.count < b.count:
+			return 1
+		}
+		return 0
+	})
+	return r
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTopK(t *testing.T) {
+	tk := newTopK(3, 2)
+
+	for i := range 100 {
+		tk.add(fmt.Sprintf("cold:%d", i))
+		for range 5 {
+			tk.add("hot")
+		}
+		if i%2 == 0 {
+			tk.add("warm")
+		}
+		if i%4 == 0 {
+			tk.add("lukewarm")
+		}
+	}
+
+	top := tk.top()
+	if len(top) != 3 {
+		t.Fatalf("want 3 keys, got %v", top)
+	}
+	for i, want := range []string{"hot", "warm", "lukewarm"} {
+		if top[i].key != want {
+			t.Errorf("want key %d to be %q, got %v", i, want, top)
+		}
+	}
+	if top[0].count < 500 {
+		t.Errorf("count must never be underestimated, got %d", top[0].count)
+	}
+
+	tk.rotate()
+	tk.add("new")
+	if top := tk.top(); top[0].key != "hot" {
+		t.Errorf("previous slice must still be counted, got %v", top)
+	}
+
+	tk.rotate()
+	if top := tk.top(); len(top) != 0 {
+		t.Errorf("keys outside of the window must be dropped, got %v", top)
+	}
+	tk.add("new")
+	if top := tk.top(); len(top) != 1 || top[0].key != "new" || top[0].count != 2 {
+		t.Errorf("want only the new key, got %v", top)
+	}
+}